@@ -0,0 +1,474 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAzureDevopsBaseURL is the base URL of the Azure DevOps Services
+	// API
+	DefaultAzureDevopsBaseURL = "https://dev.azure.com/"
+
+	// azureDevopsAPIVersion is the version of the Azure DevOps API used
+	azureDevopsAPIVersion = "7.0"
+
+	// azureDevopsPageSize is the number of work items fetched per batch
+	azureDevopsPageSize = 200
+)
+
+// azureDevopsClosedStates are the work item states considered closed
+var azureDevopsClosedStates = []string{"Closed", "Done", "Removed"}
+
+// wiqlRe matches a WIQL query
+var wiqlRe = regexp.MustCompile(`(?i)^\s*select\s`)
+
+// AzureDevopsProvider is the IssueProvider for the Azure DevOps Boards work
+// items
+type AzureDevopsProvider struct {
+	client       *http.Client
+	BaseURL      *url.URL
+	Organization string
+	Project      string
+	Token        string
+
+	mu  sync.Mutex
+	ids map[string][]int
+}
+
+// NewAzureDevopsProvider creates an AzureDevopsProvider instance for the
+// project of an organization, authenticated with a personal access token.
+// The project can be empty to query the work items of all the projects.
+//
+// If the provided httpClient is nil http.DefaultClient is used.
+func NewAzureDevopsProvider(httpClient *http.Client, organization string, project string, token string) *AzureDevopsProvider {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	baseURL, _ := url.Parse(DefaultAzureDevopsBaseURL)
+	provider := &AzureDevopsProvider{
+		client:       httpClient,
+		BaseURL:      baseURL,
+		Organization: organization,
+		Project:      project,
+		Token:        token,
+		ids:          make(map[string][]int),
+	}
+	return provider
+}
+
+// azureDevopsConnectionData represents the Azure DevOps connection data
+type azureDevopsConnectionData struct {
+	AuthenticatedUser struct {
+		ProviderDisplayName string `json:"providerDisplayName"`
+		Properties          struct {
+			Account struct {
+				Value string `json:"$value"`
+			} `json:"Account"`
+		} `json:"properties"`
+	} `json:"authenticatedUser"`
+}
+
+// azureDevopsWorkItem represents an Azure DevOps work item
+type azureDevopsWorkItem struct {
+	ID     int                    `json:"id"`
+	URL    string                 `json:"url"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// Authorize gets authentication information
+func (ap *AzureDevopsProvider) Authorize(ctx context.Context) (*User, error) {
+	req, err := ap.newRequest("GET", url.PathEscape(ap.Organization)+"/_apis/connectionData", nil)
+	if err != nil {
+		return nil, err
+	}
+	data := &azureDevopsConnectionData{}
+	_, err = doJSON(ctx, ap.client, req, data)
+	if err != nil {
+		return nil, err
+	}
+	result := &User{
+		Login: data.AuthenticatedUser.Properties.Account.Value,
+	}
+	if result.Login == "" {
+		result.Login = data.AuthenticatedUser.ProviderDisplayName
+	}
+	return result, nil
+}
+
+// Search returns a page of the Issues that match the query
+//
+// The query can be a WIQL query or Github style qualifiers. The qualifiers
+// is:, state:, author:, assignee:, label:, that matches the tags, milestone:,
+// that matches the iteration path, repo:, that selects a project, and the
+// free text terms, matched against the title, are translated to WIQL.
+//
+// The WIQL query is run for the first page and the details of the work
+// items are fetched in batches, one per page.
+func (ap *AzureDevopsProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	wiql, err := ap.buildWIQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := 0
+	if options.Page != "" {
+		offset, err = strconv.Atoi(options.Page)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ap.mu.Lock()
+	ids, ok := ap.ids[wiql]
+	ap.mu.Unlock()
+	if !ok || offset == 0 {
+		ids, err = ap.runWIQL(ctx, wiql)
+		if err != nil {
+			return nil, err
+		}
+		ap.mu.Lock()
+		ap.ids[wiql] = ids
+		ap.mu.Unlock()
+	}
+
+	result := &SearchResult{}
+	if offset >= len(ids) {
+		return result, nil
+	}
+	end := offset + azureDevopsPageSize
+	if end > len(ids) {
+		end = len(ids)
+	}
+	workItems, err := ap.getWorkItems(ctx, ids[offset:end], options.IncludeBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// process page results
+	for _, v := range workItems {
+		result.Issues = append(result.Issues, ap.newIssueFromAzureDevops(&v))
+	}
+
+	// process pagination
+	if end < len(ids) {
+		result.NextPage = strconv.Itoa(end)
+	}
+	return result, nil
+}
+
+// newRequest creates an authenticated request for the API path, relative to
+// BaseURL, with a JSON body if provided
+func (ap *AzureDevopsProvider) newRequest(method string, path string, body interface{}) (*http.Request, error) {
+	u, err := ap.BaseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	params := u.Query()
+	params.Set("api-version", azureDevopsAPIVersion)
+	u.RawQuery = params.Encode()
+
+	var data []byte
+	if body != nil {
+		data, err = json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if ap.Token != "" {
+		req.SetBasicAuth("", ap.Token)
+	}
+	return req, nil
+}
+
+// runWIQL runs a WIQL query and returns the ids of the work items
+func (ap *AzureDevopsProvider) runWIQL(ctx context.Context, wiql string) ([]int, error) {
+	path := url.PathEscape(ap.Organization) + "/"
+	if ap.Project != "" {
+		path += url.PathEscape(ap.Project) + "/"
+	}
+	req, err := ap.newRequest("POST", path+"_apis/wit/wiql", map[string]string{"query": wiql})
+	if err != nil {
+		return nil, err
+	}
+	var data struct {
+		WorkItems []struct {
+			ID int `json:"id"`
+		} `json:"workItems"`
+	}
+	_, err = doJSON(ctx, ap.client, req, &data)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(data.WorkItems))
+	for i, v := range data.WorkItems {
+		ids[i] = v.ID
+	}
+	return ids, nil
+}
+
+// getWorkItems fetches the details of a batch of work items
+func (ap *AzureDevopsProvider) getWorkItems(ctx context.Context, ids []int, includeBody bool) ([]azureDevopsWorkItem, error) {
+	fields := []string{"System.Id", "System.Title", "System.State", "System.TeamProject", "System.CreatedBy", "System.AssignedTo", "System.Tags", "System.IterationPath", "System.CreatedDate", "System.ChangedDate", "Microsoft.VSTS.Common.ClosedDate", "System.CommentCount"}
+	if includeBody {
+		fields = append(fields, "System.Description")
+	}
+	body := map[string]interface{}{
+		"ids":    ids,
+		"fields": fields,
+	}
+	req, err := ap.newRequest("POST", url.PathEscape(ap.Organization)+"/_apis/wit/workitemsbatch", body)
+	if err != nil {
+		return nil, err
+	}
+	var data struct {
+		Value []azureDevopsWorkItem `json:"value"`
+	}
+	_, err = doJSON(ctx, ap.client, req, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data.Value, nil
+}
+
+// ValidateQuery validates the query if it isn't WIQL
+func (ap *AzureDevopsProvider) ValidateQuery(query string) error {
+	if _, ok := rawWIQL(query); ok {
+		return nil
+	}
+	_, err := ParseSearchQuery(query)
+	return err
+}
+
+// rawWIQL returns the WIQL of the query, without the type qualifier, if the
+// query is already WIQL
+func rawWIQL(query string) (string, bool) {
+	raw := strings.TrimSpace(query)
+	raw = strings.TrimSpace(strings.TrimPrefix(raw, "type:issue "))
+	return raw, wiqlRe.MatchString(raw)
+}
+
+// buildWIQL translates a search query to WIQL
+func (ap *AzureDevopsProvider) buildWIQL(query string) (string, error) {
+	// the query is already WIQL
+	if raw, ok := rawWIQL(query); ok {
+		return raw, nil
+	}
+
+	terms := parseSearchTerms(query)
+	terms.remove("type", "issue")
+
+	var clauses []string
+	if projects := terms.qualifiers["repo"]; len(projects) > 0 {
+		for i, project := range projects {
+			// repo:organization/repository selects the repository project
+			projects[i] = wiqlString(project[strings.LastIndex(project, "/")+1:])
+		}
+		clauses = append(clauses, fmt.Sprintf("[System.TeamProject] IN (%s)", strings.Join(projects, ", ")))
+	} else if ap.Project != "" {
+		clauses = append(clauses, "[System.TeamProject] = @project")
+	}
+	terms.remove("repo")
+
+	closedStates := make([]string, len(azureDevopsClosedStates))
+	for i, state := range azureDevopsClosedStates {
+		closedStates[i] = wiqlString(state)
+	}
+	switch terms.state() {
+	case "open":
+		clauses = append(clauses, fmt.Sprintf("[System.State] NOT IN (%s)", strings.Join(closedStates, ", ")))
+	case "closed":
+		clauses = append(clauses, fmt.Sprintf("[System.State] IN (%s)", strings.Join(closedStates, ", ")))
+	}
+	terms.remove("is", "open", "closed", "issue")
+	terms.remove("state", "open", "closed")
+
+	fields := map[string]string{
+		"author":    "[System.CreatedBy] = %s",
+		"assignee":  "[System.AssignedTo] = %s",
+		"label":     "[System.Tags] CONTAINS %s",
+		"milestone": "[System.IterationPath] UNDER %s",
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, v := range terms.qualifiers[key] {
+			clauses = append(clauses, fmt.Sprintf(fields[key], wiqlValue(v)))
+		}
+		for _, v := range terms.qualifiers["-"+key] {
+			clauses = append(clauses, "NOT "+fmt.Sprintf(fields[key], wiqlValue(v)))
+		}
+		terms.remove(key)
+		terms.remove("-" + key)
+	}
+	for _, word := range terms.text {
+		clauses = append(clauses, fmt.Sprintf("[System.Title] CONTAINS %s", wiqlString(word)))
+	}
+
+	terms.remove("archived", "false")
+	if err := terms.unsupported("azure devops"); err != nil {
+		return "", err
+	}
+
+	wiql := "SELECT [System.Id] FROM WorkItems"
+	if len(clauses) > 0 {
+		wiql += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	wiql += " ORDER BY [System.Id]"
+	return wiql, nil
+}
+
+// wiqlValue returns the WIQL value for a user or field value, @me being the
+// current user
+func wiqlValue(v string) string {
+	if v == "@me" {
+		return "@Me"
+	}
+	return wiqlString(v)
+}
+
+// wiqlString returns a quoted WIQL string
+func wiqlString(v string) string {
+	return "'" + strings.Replace(v, "'", "''", -1) + "'"
+}
+
+// azureDevopsOrganization returns the organization of a work item URL, in
+// the https://dev.azure.com/{organization}/... or the legacy
+// https://{organization}.visualstudio.com/... forms
+func azureDevopsOrganization(workItemURL string) string {
+	u, err := url.Parse(workItemURL)
+	if err != nil {
+		return ""
+	}
+	if strings.HasSuffix(u.Host, ".visualstudio.com") {
+		return strings.TrimSuffix(u.Host, ".visualstudio.com")
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	return parts[0]
+}
+
+// newIssueFromAzureDevops creates an Issue from an Azure DevOps work item.
+//
+// The organization is resolved from the work item URL and the project is
+// used as the repository.
+func (ap *AzureDevopsProvider) newIssueFromAzureDevops(v *azureDevopsWorkItem) Issue {
+	title, _ := v.Fields["System.Title"].(string)
+	state, _ := v.Fields["System.State"].(string)
+	project, _ := v.Fields["System.TeamProject"].(string)
+	item := Issue{
+		Number:       v.ID,
+		Title:        title,
+		State:        "open",
+		URL:          v.URL,
+		Organization: azureDevopsOrganization(v.URL),
+		Repository:   project,
+	}
+	if containsFold(azureDevopsClosedStates, state) {
+		item.State = "closed"
+	}
+	if item.Organization == "" {
+		item.Organization = ap.Organization
+	}
+	if t := azureDevopsTime(v.Fields["System.CreatedDate"]); t != nil {
+		item.CreatedAt = *t
+	}
+	if t := azureDevopsTime(v.Fields["System.ChangedDate"]); t != nil {
+		item.UpdatedAt = *t
+	}
+	item.ClosedAt = azureDevopsTime(v.Fields["Microsoft.VSTS.Common.ClosedDate"])
+	item.Author.Login = azureDevopsIdentity(v.Fields["System.CreatedBy"])
+	if assignee := azureDevopsIdentity(v.Fields["System.AssignedTo"]); assignee != "" {
+		item.Assignees = []User{{Login: assignee}}
+	}
+	// the JSON numbers are decoded as float64
+	if count, ok := v.Fields["System.CommentCount"].(float64); ok {
+		item.CommentsCount = int(count)
+	}
+	// the description is HTML
+	item.Body, _ = v.Fields["System.Description"].(string)
+	tags, _ := v.Fields["System.Tags"].(string)
+	for _, tag := range strings.Split(tags, ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			item.Labels = append(item.Labels, Label{Name: tag})
+		}
+	}
+	// the iteration dates aren't fields of the work item
+	if iteration, _ := v.Fields["System.IterationPath"].(string); iteration != "" {
+		item.Milestone = &Milestone{
+			Title: iteration,
+		}
+	}
+	path := fmt.Sprintf("%s/%s/_workitems/edit/%d", url.PathEscape(item.Organization), url.PathEscape(project), v.ID)
+	if u, err := ap.BaseURL.Parse(path); err == nil {
+		item.HTMLURL = u.String()
+	}
+	return item
+}
+
+// azureDevopsIdentity returns the unique name, usually the email address, or
+// the display name of an identity field of a work item
+func azureDevopsIdentity(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		// older API versions return "Display Name <unique name>"
+		if start, end := strings.LastIndex(v, "<"), strings.LastIndex(v, ">"); start >= 0 && end > start {
+			return v[start+1 : end]
+		}
+		return v
+	case map[string]interface{}:
+		if name, _ := v["uniqueName"].(string); name != "" {
+			return name
+		}
+		name, _ := v["displayName"].(string)
+		return name
+	}
+	return ""
+}
+
+// azureDevopsTime returns the time of a date field of a work item or nil if
+// the field is missing
+func azureDevopsTime(value interface{}) *time.Time {
+	s, _ := value.(string)
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,260 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// azureDevopsSetup sets up a test HTTP server along with an
+// AzureDevopsProvider that is configured to talk to that test server.
+func azureDevopsSetup(t *testing.T) (provider *issues2markdown.AzureDevopsProvider, mux *http.ServeMux, serverURL string, teardown func()) {
+	mux = http.NewServeMux()
+	mux.HandleFunc("/organization/_apis/connectionData", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		username, token, ok := r.BasicAuth()
+		if !ok || username != "" || token != "azure_token" {
+			t.Errorf("Expected basic authentication but got %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"authenticatedUser": {"providerDisplayName": "User Name", "properties": {"Account": {"$type": "System.String", "$value": "user@example.com"}}}}`)
+	})
+	server := httptest.NewServer(mux)
+	provider = issues2markdown.NewAzureDevopsProvider(nil, "organization", "Project", "azure_token")
+	provider.BaseURL, _ = url.Parse(server.URL + "/")
+	return provider, mux, server.URL, server.Close
+}
+
+func TestAzureDevopsProviderAuthorize(t *testing.T) {
+	provider, _, _, teardown := azureDevopsSetup(t)
+	defer teardown()
+
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "user@example.com" {
+		t.Fatalf("Expected login %q but got %q", "user@example.com", user.Login)
+	}
+}
+
+func TestAzureDevopsProviderSearchWIQL(t *testing.T) {
+	tests := []struct {
+		query string
+		wiql  string
+	}{
+		{
+			query: "type:issue is:open author:@me archived:false",
+			wiql:  "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project AND [System.State] NOT IN ('Closed', 'Done', 'Removed') AND [System.CreatedBy] = @Me ORDER BY [System.Id]",
+		},
+		{
+			query: "type:issue is:closed repo:organization/Other label:backend -label:wontfix milestone:\"Sprint 1\" login crash",
+			wiql:  "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] IN ('Other') AND [System.State] IN ('Closed', 'Done', 'Removed') AND [System.Tags] CONTAINS 'backend' AND NOT [System.Tags] CONTAINS 'wontfix' AND [System.IterationPath] UNDER 'Sprint 1' AND [System.Title] CONTAINS 'login' AND [System.Title] CONTAINS 'crash' ORDER BY [System.Id]",
+		},
+		{
+			query: "type:issue SELECT [System.Id] FROM WorkItems WHERE [System.WorkItemType] = 'Bug'",
+			wiql:  "SELECT [System.Id] FROM WorkItems WHERE [System.WorkItemType] = 'Bug'",
+		},
+	}
+	for _, test := range tests {
+		provider, mux, _, teardown := azureDevopsSetup(t)
+		mux.HandleFunc("/organization/Project/_apis/wit/wiql", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "POST")
+			var body struct {
+				Query string `json:"query"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Query != test.wiql {
+				t.Errorf("Expected WIQL %q for query %q but got %q", test.wiql, test.query, body.Query)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"workItems": []}`)
+		})
+
+		_, err := provider.Search(context.Background(), test.query, &issues2markdown.SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		teardown()
+	}
+}
+
+func TestAzureDevopsProviderQueryRender(t *testing.T) {
+	provider, mux, serverURL, teardown := azureDevopsSetup(t)
+	mux.HandleFunc("/organization/Project/_apis/wit/wiql", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		w.Header().Set("Content-Type", "application/json")
+		workItems := ""
+		for id := 1; id <= 201; id++ {
+			if id > 1 {
+				workItems += ","
+			}
+			workItems += fmt.Sprintf(`{"id": %d}`, id)
+		}
+		fmt.Fprintf(w, `{"workItems": [%s]}`, workItems)
+	})
+	batches := 0
+	mux.HandleFunc("/organization/_apis/wit/workitemsbatch", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		batches++
+		var body struct {
+			IDs []int `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		workItem := `{"id": %d, "url": "https://dev.azure.com/organization/6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c/_apis/wit/workItems/%d", "fields": {"System.Id": %d, "System.Title": "Work item title %d", "System.State": "%s", "System.TeamProject": "Project"}}`
+		switch len(body.IDs) {
+		case 200:
+			fmt.Fprintf(w, `{"count": 2, "value": [`+workItem+`, `+workItem+`]}`, 1, 1, 1, 1, "Active", 2, 2, 2, 2, "Done")
+		case 1:
+			fmt.Fprintf(w, `{"count": 1, "value": [`+workItem+`]}`, 201, 201, 201, 201, "Closed")
+		default:
+			t.Errorf("Unexpected batch of %d work items", len(body.IDs))
+		}
+	})
+	defer teardown()
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "is:open")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batches != 2 {
+		t.Fatalf("Expected %d batches but got %d", 2, batches)
+	}
+	markdown, err := i2md.Render(issues, issues2markdown.NewRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMarkdown := fmt.Sprintf(`- [ ] organization/Project : [#1 Work item title 1](%[1]s/organization/Project/_workitems/edit/1)
+- [x] organization/Project : [#2 Work item title 2](%[1]s/organization/Project/_workitems/edit/2)
+- [x] organization/Project : [#201 Work item title 201](%[1]s/organization/Project/_workitems/edit/201)`, serverURL)
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestAzureDevopsProviderQueryRawWIQL(t *testing.T) {
+	provider, mux, _, teardown := azureDevopsSetup(t)
+	var wiql []string
+	mux.HandleFunc("/organization/Project/_apis/wit/wiql", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		wiql = append(wiql, body.Query)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"workItems": []}`)
+	})
+	defer teardown()
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedWIQL := "SELECT [System.Id] FROM WorkItems WHERE [System.ChangedDate] > '2024-01-01T00:00:00Z'"
+	if _, err := i2md.Query(issues2markdown.NewQueryOptions(), expectedWIQL); err != nil {
+		t.Fatal(err)
+	}
+	if len(wiql) != 1 || wiql[0] != expectedWIQL {
+		t.Fatalf("Expected WIQL %q but got %q", expectedWIQL, wiql)
+	}
+
+	if _, err := i2md.Query(issues2markdown.NewQueryOptions(), "is:open closed:someday"); err == nil {
+		t.Fatal("Expected an error for an invalid date")
+	}
+}
+
+func TestAzureDevopsProviderSearchIssueFields(t *testing.T) {
+	provider, mux, _, teardown := azureDevopsSetup(t)
+	mux.HandleFunc("/organization/Project/_apis/wit/wiql", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"workItems": [{"id": 1}, {"id": 2}]}`)
+	})
+	mux.HandleFunc("/organization/_apis/wit/workitemsbatch", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var body struct {
+			Fields []string `json:"fields"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range []string{"System.CreatedBy", "System.AssignedTo", "System.Tags", "System.IterationPath"} {
+			if !containsString(body.Fields, field) {
+				t.Errorf("Expected field %q in %v", field, body.Fields)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"count": 2, "value": [
+			{"id": 1, "url": "https://dev.azure.com/organization/_apis/wit/workItems/1", "fields": {"System.Title": "Work item title 1", "System.State": "Active", "System.TeamProject": "Project",
+				"System.CreatedBy": {"displayName": "User Name", "uniqueName": "user@example.com"},
+				"System.AssignedTo": {"displayName": "Octo Cat"},
+				"System.Tags": "backend; needs triage",
+				"System.IterationPath": "Project\\Sprint 1",
+				"System.CreatedDate": "2018-10-01T10:00:00.123Z", "System.ChangedDate": "2018-10-02T10:00:00Z", "Microsoft.VSTS.Common.ClosedDate": "2018-10-03T10:00:00Z"}},
+			{"id": 2, "url": "https://dev.azure.com/organization/_apis/wit/workItems/2", "fields": {"System.Title": "Work item title 2", "System.State": "Active", "System.TeamProject": "Project",
+				"System.CreatedBy": "User Name <user@example.com>"}}]}`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 2 {
+		t.Fatalf("Expected %d issues but got %d", 2, len(result.Issues))
+	}
+	testIssueFields(t, result.Issues[0], issues2markdown.Issue{
+		Author:    issues2markdown.User{Login: "user@example.com"},
+		Assignees: []issues2markdown.User{{Login: "Octo Cat"}},
+		Labels:    []issues2markdown.Label{{Name: "backend"}, {Name: "needs triage"}},
+		Milestone: &issues2markdown.Milestone{Title: `Project\Sprint 1`},
+	})
+	testIssueTimes(t, result.Issues[0], "2018-10-01T10:00:00.123Z", "2018-10-02T10:00:00Z", "2018-10-03T10:00:00Z")
+	testIssueFields(t, result.Issues[1], issues2markdown.Issue{
+		Author: issues2markdown.User{Login: "user@example.com"},
+	})
+}
+
+// containsString reports whether the value is in the list
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,409 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBitbucketBaseURL is the base URL of the Bitbucket Cloud API
+	DefaultBitbucketBaseURL = "https://api.bitbucket.org/"
+
+	// bitbucketPageSize is the number of issues requested per page
+	bitbucketPageSize = 50
+)
+
+// bitbucketOpenStates are the Bitbucket issue states considered open
+var bitbucketOpenStates = []string{"new", "open", "on hold"}
+
+// bitbucketClosedStates are the Bitbucket issue states considered closed
+var bitbucketClosedStates = []string{"resolved", "closed", "invalid", "duplicate", "wontfix"}
+
+// BitbucketProvider is the IssueProvider for the Bitbucket Cloud API
+type BitbucketProvider struct {
+	client   *http.Client
+	BaseURL  *url.URL
+	Username string
+	Token    string
+	// Workspace is the workspace of the queries without repo:, org: or
+	// user: qualifier, like the DefaultQuery
+	Workspace string
+
+	mu           sync.Mutex
+	repositories map[string][]string
+}
+
+// NewBitbucketProvider creates a BitbucketProvider instance authenticated
+// with a username and an app password, or with an access token and an empty
+// username.
+//
+// If the provided httpClient is nil http.DefaultClient is used.
+func NewBitbucketProvider(httpClient *http.Client, username string, token string) *BitbucketProvider {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	baseURL, _ := url.Parse(DefaultBitbucketBaseURL)
+	provider := &BitbucketProvider{
+		client:       httpClient,
+		BaseURL:      baseURL,
+		Username:     username,
+		Token:        token,
+		repositories: make(map[string][]string),
+	}
+	return provider
+}
+
+// bitbucketUser represents a Bitbucket user
+type bitbucketUser struct {
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+}
+
+// login returns the user name or the nickname, as the user name isn't
+// always exposed
+func (u *bitbucketUser) login() string {
+	if u.Username != "" {
+		return u.Username
+	}
+	return u.Nickname
+}
+
+// bitbucketLink represents a Bitbucket link
+type bitbucketLink struct {
+	Href string `json:"href"`
+}
+
+// bitbucketIssue represents a Bitbucket issue
+type bitbucketIssue struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	State string `json:"state"`
+	Links struct {
+		Self bitbucketLink `json:"self"`
+		HTML bitbucketLink `json:"html"`
+	} `json:"links"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Reporter  *bitbucketUser `json:"reporter"`
+	Assignee  *bitbucketUser `json:"assignee"`
+	Component *struct {
+		Name string `json:"name"`
+	} `json:"component"`
+	Milestone *struct {
+		Name string `json:"name"`
+	} `json:"milestone"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	Votes     int       `json:"votes"`
+	CreatedOn time.Time `json:"created_on"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+// bitbucketIssuesPage represents a page of Bitbucket issues
+type bitbucketIssuesPage struct {
+	Values []bitbucketIssue `json:"values"`
+	Next   string           `json:"next"`
+}
+
+// bitbucketRepositoriesPage represents a page of Bitbucket repositories
+type bitbucketRepositoriesPage struct {
+	Values []struct {
+		Slug string `json:"slug"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+// Authorize gets authentication information
+func (bp *BitbucketProvider) Authorize(ctx context.Context) (*User, error) {
+	req, err := bp.newRequest("2.0/user")
+	if err != nil {
+		return nil, err
+	}
+	user := &bitbucketUser{}
+	_, err = doJSON(ctx, bp.client, req, user)
+	if err != nil {
+		return nil, err
+	}
+	result := &User{
+		Login: user.login(),
+	}
+	return result, nil
+}
+
+// Search returns a page of the Issues that match the query
+//
+// The repo: qualifier lists the issues of a repository and the org: and
+// user: qualifiers the issues of all the repositories of a workspace with an
+// issue tracker, the Workspace if there are none of them. The is:, state:,
+// author:, assignee:, milestone: and free text terms, matched against the
+// title, filter the list.
+//
+// The page is the URL of the next issues page, which moves to the next
+// repository of the workspace once the issues of a repository are listed.
+func (bp *BitbucketProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	workspace, slugs, filter, err := bp.parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if slugs == nil {
+		slugs, err = bp.listRepositories(ctx, workspace)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(slugs) == 0 {
+		return &SearchResult{}, nil
+	}
+
+	page := options.Page
+	if page == "" {
+		page = bp.issuesURL(workspace, slugs[0], filter)
+	}
+	req, err := bp.newRequest(page)
+	if err != nil {
+		return nil, err
+	}
+	issues := &bitbucketIssuesPage{}
+	_, err = doJSON(ctx, bp.client, req, issues)
+	if err != nil {
+		return nil, err
+	}
+
+	// process page results
+	result := &SearchResult{}
+	for _, v := range issues.Values {
+		result.Issues = append(result.Issues, newIssueFromBitbucket(&v))
+	}
+
+	// process pagination, moving to the next repository at the last page
+	result.NextPage = issues.Next
+	if result.NextPage == "" {
+		current := bitbucketRepositorySlug(req.URL)
+		for i, slug := range slugs {
+			if slug == current && i+1 < len(slugs) {
+				result.NextPage = bp.issuesURL(workspace, slugs[i+1], filter)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// newRequest creates an authenticated GET request for the API path,
+// relative to BaseURL
+func (bp *BitbucketProvider) newRequest(path string) (*http.Request, error) {
+	u, err := bp.BaseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if bp.Username != "" {
+		req.SetBasicAuth(bp.Username, bp.Token)
+	} else if bp.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+bp.Token)
+	}
+	return req, nil
+}
+
+// parseQuery returns the workspace, the repositories, nil for all the
+// repositories of the workspace, and the Bitbucket filter of the query
+func (bp *BitbucketProvider) parseQuery(query string) (string, []string, string, error) {
+	terms := parseSearchTerms(query)
+
+	workspace := ""
+	var slugs []string
+	if repo := terms.get("repo"); repo != "" {
+		parts := strings.SplitN(repo, "/", 2)
+		if len(parts) != 2 {
+			return "", nil, "", fmt.Errorf("bitbucket: invalid repository %q", repo)
+		}
+		workspace = parts[0]
+		slugs = []string{parts[1]}
+	} else if owner := terms.get("org"); owner != "" {
+		workspace = owner
+	} else if owner := terms.get("user"); owner != "" {
+		workspace = owner
+	} else if bp.Workspace != "" {
+		workspace = bp.Workspace
+	} else {
+		return "", nil, "", fmt.Errorf("bitbucket: a repo:, org: or user: qualifier or a Workspace is required")
+	}
+	terms.remove("repo")
+	terms.remove("org")
+	terms.remove("user")
+
+	var clauses []string
+	var states []string
+	switch terms.state() {
+	case "open":
+		states = bitbucketOpenStates
+	case "closed":
+		states = bitbucketClosedStates
+	}
+	if len(states) > 0 {
+		stateClauses := make([]string, len(states))
+		for i, state := range states {
+			stateClauses[i] = "state = " + strconv.Quote(state)
+		}
+		clauses = append(clauses, "("+strings.Join(stateClauses, " OR ")+")")
+	}
+	terms.remove("is", "open", "closed", "issue")
+	terms.remove("state", "open", "closed")
+
+	fields := []struct {
+		key   string
+		field string
+	}{
+		{"author", "reporter.nickname"},
+		{"assignee", "assignee.nickname"},
+		{"milestone", "milestone.name"},
+	}
+	for _, field := range fields {
+		for _, v := range terms.qualifiers[field.key] {
+			clauses = append(clauses, field.field+" = "+strconv.Quote(v))
+		}
+		terms.remove(field.key)
+	}
+	for _, word := range terms.text {
+		clauses = append(clauses, "title ~ "+strconv.Quote(word))
+	}
+
+	terms.remove("type", "issue")
+	terms.remove("archived", "false")
+	if err := terms.unsupported("bitbucket"); err != nil {
+		return "", nil, "", err
+	}
+	return workspace, slugs, strings.Join(clauses, " AND "), nil
+}
+
+// listRepositories returns the slugs of the repositories of the workspace
+// with an issue tracker
+func (bp *BitbucketProvider) listRepositories(ctx context.Context, workspace string) ([]string, error) {
+	bp.mu.Lock()
+	slugs, ok := bp.repositories[workspace]
+	bp.mu.Unlock()
+	if ok {
+		return slugs, nil
+	}
+
+	params := url.Values{}
+	params.Set("q", "has_issues = true")
+	params.Set("sort", "slug")
+	params.Set("pagelen", "100")
+	page := "2.0/repositories/" + url.PathEscape(workspace) + "?" + params.Encode()
+	slugs = []string{}
+	for page != "" {
+		req, err := bp.newRequest(page)
+		if err != nil {
+			return nil, err
+		}
+		repositories := &bitbucketRepositoriesPage{}
+		_, err = doJSON(ctx, bp.client, req, repositories)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range repositories.Values {
+			slugs = append(slugs, v.Slug)
+		}
+		page = repositories.Next
+	}
+
+	bp.mu.Lock()
+	bp.repositories[workspace] = slugs
+	bp.mu.Unlock()
+	return slugs, nil
+}
+
+// issuesURL returns the URL of the first page of the issues of a repository
+// that match the filter
+func (bp *BitbucketProvider) issuesURL(workspace string, slug string, filter string) string {
+	params := url.Values{}
+	if filter != "" {
+		params.Set("q", filter)
+	}
+	params.Set("sort", "id")
+	params.Set("pagelen", strconv.Itoa(bitbucketPageSize))
+	u, _ := bp.BaseURL.Parse("2.0/repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(slug) + "/issues")
+	u.RawQuery = params.Encode()
+	return u.String()
+}
+
+// bitbucketRepositorySlug returns the repository slug of an issues URL
+func bitbucketRepositorySlug(u *url.URL) string {
+	parts := strings.Split(strings.TrimSuffix(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[len(parts)-1] != "issues" {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
+
+// newIssueFromBitbucket creates an Issue from a Bitbucket issue
+func newIssueFromBitbucket(v *bitbucketIssue) Issue {
+	item := Issue{
+		Number:  v.ID,
+		Title:   v.Title,
+		State:   "open",
+		URL:     v.Links.Self.Href,
+		HTMLURL: v.Links.HTML.Href,
+	}
+	if containsFold(bitbucketClosedStates, v.State) {
+		item.State = "closed"
+	}
+	if parts := strings.SplitN(v.Repository.FullName, "/", 2); len(parts) == 2 {
+		item.Organization = parts[0]
+		item.Repository = parts[1]
+	}
+	// Bitbucket doesn't record when an issue was closed
+	item.Body = v.Content.Raw
+	item.Reactions = Reactions{
+		TotalCount: v.Votes,
+		PlusOne:    v.Votes,
+	}
+	item.CreatedAt = v.CreatedOn
+	item.UpdatedAt = v.UpdatedOn
+	if v.Reporter != nil {
+		item.Author.Login = v.Reporter.login()
+	}
+	if v.Assignee != nil {
+		item.Assignees = []User{{Login: v.Assignee.login()}}
+	}
+	// Bitbucket issues have no labels, the component is the closest thing
+	if v.Component != nil {
+		item.Labels = []Label{{Name: v.Component.Name}}
+	}
+	// Bitbucket milestones have no due date
+	if v.Milestone != nil {
+		item.Milestone = &Milestone{
+			Title: v.Milestone.Name,
+		}
+	}
+	return item
+}
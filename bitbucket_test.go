@@ -0,0 +1,222 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// bitbucketSetup sets up a test HTTP server along with a BitbucketProvider
+// that is configured to talk to that test server.
+func bitbucketSetup(t *testing.T) (provider *issues2markdown.BitbucketProvider, mux *http.ServeMux, serverURL string, teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	provider = issues2markdown.NewBitbucketProvider(nil, "username", "app_password")
+	provider.BaseURL, _ = url.Parse(server.URL + "/")
+	return provider, mux, server.URL, server.Close
+}
+
+func TestBitbucketProviderAuthorize(t *testing.T) {
+	provider, mux, _, teardown := bitbucketSetup(t)
+	mux.HandleFunc("/2.0/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "username" || password != "app_password" {
+			t.Errorf("Expected basic authentication but got %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"nickname": "username", "account_id": "557058:c0b72ad0"}`)
+	})
+	defer teardown()
+
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "username" {
+		t.Fatalf("Expected login %q but got %q", "username", user.Login)
+	}
+}
+
+func TestBitbucketProviderQueryWorkspace(t *testing.T) {
+	provider, mux, serverURL, teardown := bitbucketSetup(t)
+	mux.HandleFunc("/2.0/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"username": "username"}`)
+	})
+	repositoriesRequests := 0
+	mux.HandleFunc("/2.0/repositories/workspace", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		repositoriesRequests++
+		if got := r.URL.Query().Get("q"); got != "has_issues = true" {
+			t.Errorf("Expected repositories filter %q but got %q", "has_issues = true", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprintf(w, `{"values": [{"slug": "first"}], "next": "%s/2.0/repositories/workspace?q=has_issues+%%3D+true&page=2"}`, serverURL)
+			return
+		}
+		fmt.Fprint(w, `{"values": [{"slug": "second"}]}`)
+	})
+	issue := `{"id": %d, "title": "Issue title %d", "state": "%s", "links": {"self": {"href": "https://api.bitbucket.org/2.0/repositories/workspace/%s/issues/%d"}, "html": {"href": "https://bitbucket.org/workspace/%s/issues/%d"}}, "repository": {"full_name": "workspace/%s"}}`
+	mux.HandleFunc("/2.0/repositories/workspace/first/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		expectedFilter := `(state = "new" OR state = "open" OR state = "on hold") AND reporter.nickname = "username"`
+		if got := r.URL.Query().Get("q"); got != expectedFilter {
+			t.Errorf("Expected issues filter %q but got %q", expectedFilter, got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprintf(w, `{"values": [`+issue+`], "next": "%s/2.0/repositories/workspace/first/issues?q=%s&page=2"}`,
+				1, 1, "new", "first", 1, "first", 1, "first", serverURL, url.QueryEscape(expectedFilter))
+			return
+		}
+		fmt.Fprintf(w, `{"values": [`+issue+`]}`, 2, 2, "on hold", "first", 2, "first", 2, "first")
+	})
+	mux.HandleFunc("/2.0/repositories/workspace/second/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"values": [`+issue+`]}`, 1, 1, "wontfix", "second", 1, "second", 1, "second")
+	})
+	defer teardown()
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "is:open org:workspace author:username archived:false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := i2md.Render(issues, issues2markdown.NewRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMarkdown := `- [ ] workspace/first : [#1 Issue title 1](https://bitbucket.org/workspace/first/issues/1)
+- [ ] workspace/first : [#2 Issue title 2](https://bitbucket.org/workspace/first/issues/2)
+- [x] workspace/second : [#1 Issue title 1](https://bitbucket.org/workspace/second/issues/1)`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+	if repositoriesRequests != 2 {
+		t.Fatalf("Expected the workspace repositories to be listed once but got %d requests", repositoriesRequests)
+	}
+}
+
+func TestBitbucketProviderSearchRepository(t *testing.T) {
+	provider, mux, _, teardown := bitbucketSetup(t)
+	mux.HandleFunc("/2.0/repositories/workspace/repository/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		expectedFilter := `(state = "resolved" OR state = "closed" OR state = "invalid" OR state = "duplicate" OR state = "wontfix") AND title ~ "crash"`
+		if got := r.URL.Query().Get("q"); got != expectedFilter {
+			t.Errorf("Expected issues filter %q but got %q", expectedFilter, got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"values": []}`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "type:issue is:closed repo:workspace/repository crash", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 0 || result.NextPage != "" {
+		t.Fatalf("Expected an empty result but got %+v", result)
+	}
+}
+
+func TestBitbucketProviderQueryDefaultWorkspace(t *testing.T) {
+	provider, mux, _, teardown := bitbucketSetup(t)
+	provider.Workspace = "workspace"
+	mux.HandleFunc("/2.0/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"username": "username"}`)
+	})
+	mux.HandleFunc("/2.0/repositories/workspace", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"values": [{"slug": "repository"}]}`)
+	})
+	mux.HandleFunc("/2.0/repositories/workspace/repository/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		expectedFilter := `(state = "new" OR state = "open" OR state = "on hold") AND reporter.nickname = "username"`
+		if got := r.URL.Query().Get("q"); got != expectedFilter {
+			t.Errorf("Expected issues filter %q but got %q", expectedFilter, got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"values": [{"id": 1, "title": "Issue title 1", "state": "open", "links": {"html": {"href": "https://bitbucket.org/workspace/repository/issues/1"}}, "repository": {"full_name": "workspace/repository"}}]}`)
+	})
+	defer teardown()
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Number != 1 {
+		t.Fatalf("Expected the issue #1 of the workspace but got %+v", issues)
+	}
+}
+
+func TestBitbucketProviderSearchWithoutWorkspace(t *testing.T) {
+	provider, _, _, teardown := bitbucketSetup(t)
+	defer teardown()
+
+	_, err := provider.Search(context.Background(), "type:issue is:open", &issues2markdown.SearchOptions{})
+	if err == nil {
+		t.Fatal("Expected an error for a query without workspace")
+	}
+}
+
+func TestBitbucketProviderSearchIssueFields(t *testing.T) {
+	provider, mux, _, teardown := bitbucketSetup(t)
+	mux.HandleFunc("/2.0/repositories/workspace/repository/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"values": [{"id": 1, "title": "Issue title 1", "state": "new", "repository": {"full_name": "workspace/repository"},
+			"reporter": {"nickname": "username"},
+			"assignee": {"nickname": "octocat"},
+			"component": {"name": "backend"},
+			"milestone": {"name": "v1.0"}}]}`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "repo:workspace/repository", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 {
+		t.Fatalf("Expected %d issues but got %d", 1, len(result.Issues))
+	}
+	testIssueFields(t, result.Issues[0], issues2markdown.Issue{
+		Author:    issues2markdown.User{Login: "username"},
+		Assignees: []issues2markdown.User{{Login: "octocat"}},
+		Labels:    []issues2markdown.Label{{Name: "backend"}},
+		Milestone: &issues2markdown.Milestone{Title: "v1.0"},
+	})
+}
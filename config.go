@@ -0,0 +1,134 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// QueryConfig is the configuration of the named queries, loaded from a YAML
+// or TOML file like:
+//
+//	queries:
+//	  my-open-issues:
+//	    description: Issues assigned to me
+//	    query: is:open assignee:{{ .User }}
+//	  release-blockers:
+//	    query: is:open label:blocker milestone:{{ env "RELEASE" }} updated:>={{ date "last monday" }}
+type QueryConfig struct {
+	Queries map[string]NamedQuery `yaml:"queries" toml:"queries"`
+}
+
+// NamedQuery is a query saved in a QueryConfig. The query is a template
+// executed with the QueryTemplateData of the QueryOptions.
+type NamedQuery struct {
+	Description string `yaml:"description" toml:"description"`
+	Query       string `yaml:"query" toml:"query"`
+}
+
+// LoadQueryConfig loads the named queries from a YAML file, with the .yaml or
+// .yml extension, or a TOML file, with the .toml extension
+func LoadQueryConfig(path string) (*QueryConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	config, err := ParseQueryConfig(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return config, nil
+}
+
+// ParseQueryConfig parses the named queries in the format, yaml, yml or
+// toml. The query templates are parsed too, so their errors are reported
+// before the queries are used.
+func ParseQueryConfig(data []byte, format string) (*QueryConfig, error) {
+	config := &QueryConfig{}
+	switch format {
+	case "yaml", "yml":
+		if err := yaml.UnmarshalStrict(data, config); err != nil {
+			return nil, err
+		}
+	case "toml":
+		metadata, err := toml.Decode(string(data), config)
+		if err != nil {
+			return nil, err
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("unknown key %s", undecoded[0])
+		}
+	default:
+		return nil, fmt.Errorf("unknown configuration format %q", format)
+	}
+
+	names := make([]string, 0, len(config.Queries))
+	for name := range config.Queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.TrimSpace(config.Queries[name].Query) == "" {
+			return nil, fmt.Errorf("query %q: empty query", name)
+		}
+		if _, err := newQueryTemplate(name, config.Queries[name].Query, time.Now); err != nil {
+			return nil, fmt.Errorf("query %q: %v", name, err)
+		}
+	}
+	return config, nil
+}
+
+// ResolveNamedQuery returns the query of the QueryConfig with the name, with its
+// template executed for the login of the user. The dates are relative to the
+// Now of the options.
+func (qo *QueryOptions) ResolveNamedQuery(name string, user *User) (string, error) {
+	source, err := qo.namedQuery(name)
+	if err != nil {
+		return "", err
+	}
+	options := *qo
+	if user != nil {
+		options.User = user
+	}
+	query, err := options.executeQueryTemplate(name, source)
+	if err != nil {
+		return "", fmt.Errorf("query %q: %v", name, err)
+	}
+	return query, nil
+}
+
+// namedQuery returns the template of the named query of the QueryConfig
+func (qo *QueryOptions) namedQuery(name string) (string, error) {
+	if qo.Config == nil {
+		return "", fmt.Errorf("query %q: no configuration of named queries", name)
+	}
+	named, ok := qo.Config.Queries[name]
+	if !ok {
+		return "", fmt.Errorf("query %q: unknown named query", name)
+	}
+	return named.Query, nil
+}
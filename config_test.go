@@ -0,0 +1,164 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+func TestLoadQueryConfig(t *testing.T) {
+	if err := os.Setenv("I2M_TEST_RELEASE", "v1.0"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("I2M_TEST_RELEASE")
+
+	// 2018-10-10 is a wednesday
+	now := time.Date(2018, 10, 10, 12, 0, 0, 0, time.UTC)
+	expected := map[string]string{
+		"my-open-issues":   "is:open assignee:username",
+		"team-backlog":     "is:open org:organization no:assignee",
+		"release-blockers": `is:open label:"release blocker" milestone:v1.0 updated:>=2018-10-08`,
+	}
+	for _, path := range []string{"testdata/queries.yaml", "testdata/queries.toml"} {
+		config, err := issues2markdown.LoadQueryConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if description := config.Queries["my-open-issues"].Description; description != "Issues assigned to me" {
+			t.Fatalf("Expected description %q in %s but got %q", "Issues assigned to me", path, description)
+		}
+		options := issues2markdown.NewQueryOptions()
+		options.Organization = "organization"
+		options.Config = config
+		options.Now = func() time.Time { return now }
+		for name, query := range expected {
+			got, err := options.ResolveNamedQuery(name, &issues2markdown.User{Login: "username"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != query {
+				t.Fatalf("Expected query %q for %s in %s but got %q", query, name, path, got)
+			}
+		}
+	}
+}
+
+func TestParseQueryConfigErrors(t *testing.T) {
+	tests := []struct {
+		data   string
+		format string
+	}{
+		{"queries:\n  broken:\n    query: is:open author:{{ .User\n", "yaml"},
+		{"queries:\n  empty:\n    query: \"\"\n", "yaml"},
+		{"queries:\n  typo:\n    qeury: is:open\n", "yml"},
+		{"[queries.typo]\nqeury = \"is:open\"\n", "toml"},
+		{"[queries.broken\nquery = \"is:open\"\n", "toml"},
+		{"{}", "json"},
+	}
+	for _, test := range tests {
+		if _, err := issues2markdown.ParseQueryConfig([]byte(test.data), test.format); err == nil {
+			t.Fatalf("Expected an error for %s %q", test.format, test.data)
+		}
+	}
+}
+
+func TestResolveNamedQueryErrors(t *testing.T) {
+	config, err := issues2markdown.ParseQueryConfig([]byte(`queries:
+  missing-env:
+    query: milestone:{{ env "I2M_TEST_UNSET" }}
+  bad-date:
+    query: updated:>={{ date "someday" }}
+  missing-field:
+    query: assignee:{{ .Team }}
+`), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	options.Config = config
+	for _, name := range []string{"missing-env", "bad-date", "missing-field", "unknown"} {
+		if _, err := options.ResolveNamedQuery(name, &issues2markdown.User{Login: "username"}); err == nil {
+			t.Fatalf("Expected an error for the named query %s", name)
+		}
+	}
+}
+
+func TestNamedQueryDates(t *testing.T) {
+	// 2018-10-10 is a wednesday
+	now := time.Date(2018, 10, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]string{
+		"today":          "2018-10-10",
+		"yesterday":      "2018-10-09",
+		"tomorrow":       "2018-10-11",
+		"last monday":    "2018-10-08",
+		"last wednesday": "2018-10-03",
+		"this monday":    "2018-10-08",
+		"this sunday":    "2018-10-14",
+		"next wednesday": "2018-10-17",
+		"next friday":    "2018-10-12",
+		"last week":      "2018-10-03",
+		"last month":     "2018-09-10",
+		"3 days ago":     "2018-10-07",
+		"2 weeks ago":    "2018-09-26",
+		"1 year ago":     "2017-10-10",
+		"2018-01-02":     "2018-01-02",
+	}
+	for expression, expected := range tests {
+		config, err := issues2markdown.ParseQueryConfig([]byte(`queries:
+  dated:
+    query: created:{{ date "`+expression+`" }}
+`), "yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		options := issues2markdown.NewQueryOptions()
+		options.Config = config
+		options.Now = func() time.Time { return now }
+		query, err := options.ResolveNamedQuery("dated", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != "created:"+expected {
+			t.Fatalf("Expected date %s for %q but got %q", expected, expression, query)
+		}
+	}
+}
+
+func TestQueryNamedQuery(t *testing.T) {
+	config, err := issues2markdown.LoadQueryConfig("testdata/queries.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &fakeProvider{user: &issues2markdown.User{Login: "username"}}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	options.Config = config
+	if _, err := i2md.Query(options, "my-open-issues"); err != nil {
+		t.Fatal(err)
+	}
+	if expectedQuery := "type:issue is:open assignee:username"; provider.queries[0] != expectedQuery {
+		t.Fatalf("Expected query %q but got %q", expectedQuery, provider.queries[0])
+	}
+}
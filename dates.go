@@ -0,0 +1,113 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekdays are the names of the days of the week
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// parseRelativeDate returns the day of a date expression relative to now, at
+// midnight in the location of now. The expressions are:
+//
+//	today, yesterday, tomorrow
+//	last WEEKDAY, this WEEKDAY, next WEEKDAY   like "last monday"
+//	last week, last month, last year           the same day a unit ago
+//	N days ago, N weeks ago, N months ago, N years ago
+//	YYYY-MM-DD
+//
+// This WEEKDAY is in the week of now, which starts on monday.
+func parseRelativeDate(expression string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	words := strings.Fields(strings.ToLower(expression))
+	switch len(words) {
+	case 1:
+		switch words[0] {
+		case "today":
+			return today, nil
+		case "yesterday":
+			return today.AddDate(0, 0, -1), nil
+		case "tomorrow":
+			return today.AddDate(0, 0, 1), nil
+		}
+		if t, err := time.ParseInLocation(searchDateLayout, words[0], now.Location()); err == nil {
+			return t, nil
+		}
+	case 2:
+		if weekday, ok := weekdays[words[1]]; ok {
+			days := int(weekday - today.Weekday())
+			switch words[0] {
+			case "last":
+				if days >= 0 {
+					days -= 7
+				}
+				return today.AddDate(0, 0, days), nil
+			case "next":
+				if days <= 0 {
+					days += 7
+				}
+				return today.AddDate(0, 0, days), nil
+			case "this":
+				// the weeks start on monday
+				days = (int(weekday)+6)%7 - (int(today.Weekday())+6)%7
+				return today.AddDate(0, 0, days), nil
+			}
+		}
+		if words[0] == "last" {
+			if t, ok := addDateUnits(today, words[1], -1); ok {
+				return t, nil
+			}
+		}
+	case 3:
+		n, err := strconv.Atoi(words[0])
+		if err == nil && n >= 0 && words[2] == "ago" {
+			if t, ok := addDateUnits(today, strings.TrimSuffix(words[1], "s"), -n); ok {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date %q", expression)
+}
+
+// addDateUnits adds n units of day, week, month or year to t
+func addDateUnits(t time.Time, unit string, n int) (time.Time, bool) {
+	switch unit {
+	case "day":
+		return t.AddDate(0, 0, n), true
+	case "week":
+		return t.AddDate(0, 0, 7*n), true
+	case "month":
+		return t.AddDate(0, n, 0), true
+	case "year":
+		return t.AddDate(n, 0, 0), true
+	}
+	return time.Time{}, false
+}
@@ -18,4 +18,9 @@
 // Package issues2markdown queries issues and renders the result to markdown
 // following the Github flavored Markdown task lists format described
 // at https://help.github.com/articles/about-task-lists/.
+//
+// The issues are queried through the IssueProvider passed to
+// NewIssuesToMarkdown. Github is queried either with the REST search API,
+// using NewGithubProvider, or with the GraphQL API, using
+// NewGithubGraphQLProvider.
 package issues2markdown
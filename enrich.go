@@ -0,0 +1,177 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	// DefaultEnrichConcurrency is the default number of Issues enriched at
+	// the same time
+	DefaultEnrichConcurrency = 4
+)
+
+// EnrichOptions are the available options to modify the enrichment of issues
+type EnrichOptions struct {
+	// Comments fetches the comments of the Issues, the provider must be a
+	// CommentsProvider
+	Comments bool
+	// MaxComments keeps only the latest comments, all of them if 0
+	MaxComments int
+	// Timeline fetches the timeline events of the Issues, the provider must
+	// be a TimelineProvider
+	Timeline bool
+	// EventTypes keeps only the events of these types, all of them if empty
+	EventTypes []string
+	// LinkedPullRequests fetches the pull requests that reference or close
+	// the Issues, the provider must be a LinkedPullRequestsProvider
+	LinkedPullRequests bool
+	// ClosingPullRequestsOnly keeps only the linked pull requests that close
+	// the Issues
+	ClosingPullRequestsOnly bool
+	// ReviewState fetches the review state of the pull requests, the provider
+	// must be a ReviewStateProvider
+	ReviewState bool
+	// Concurrency is the maximum number of Issues enriched at the same time
+	Concurrency int
+}
+
+// NewEnrichOptions creates a new EnrichOptions instance with sensible
+// defaults
+func NewEnrichOptions() *EnrichOptions {
+	options := &EnrichOptions{
+		Concurrency: DefaultEnrichConcurrency,
+	}
+	return options
+}
+
+// Enrich fetches the comments, timeline events, linked pull requests and
+// review states of the Issues returned by Query, with one request or more
+// per Issue
+func (im *IssuesToMarkdown) Enrich(issues []Issue, options *EnrichOptions) error {
+	if !options.Comments && !options.Timeline && !options.LinkedPullRequests && !options.ReviewState {
+		return nil
+	}
+	commentsProvider, ok := im.provider.(CommentsProvider)
+	if options.Comments && !ok {
+		return fmt.Errorf("comments aren't supported by the provider")
+	}
+	timelineProvider, ok := im.provider.(TimelineProvider)
+	if options.Timeline && !ok {
+		return fmt.Errorf("timelines aren't supported by the provider")
+	}
+	pullRequestsProvider, ok := im.provider.(LinkedPullRequestsProvider)
+	if options.LinkedPullRequests && !ok {
+		return fmt.Errorf("linked pull requests aren't supported by the provider")
+	}
+	reviewStateProvider, ok := im.provider.(ReviewStateProvider)
+	if options.ReviewState && !ok {
+		return fmt.Errorf("review states aren't supported by the provider")
+	}
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultEnrichConcurrency
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	semaphore := make(chan struct{}, concurrency)
+	for i := range issues {
+		semaphore <- struct{}{}
+		if ctx.Err() != nil {
+			<-semaphore
+			break
+		}
+		wg.Add(1)
+		go func(issue *Issue) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := enrichIssue(ctx, issue, commentsProvider, timelineProvider, pullRequestsProvider, reviewStateProvider, options); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("#%d: %v", issue.Number, err)
+					cancel()
+				})
+			}
+		}(&issues[i])
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// enrichIssue fetches the comments, timeline events, linked pull requests and
+// review state of an Issue
+func enrichIssue(ctx context.Context, issue *Issue, commentsProvider CommentsProvider, timelineProvider TimelineProvider, pullRequestsProvider LinkedPullRequestsProvider, reviewStateProvider ReviewStateProvider, options *EnrichOptions) error {
+	if options.Comments {
+		comments, err := commentsProvider.Comments(ctx, issue)
+		if err != nil {
+			return err
+		}
+		if options.MaxComments > 0 && len(comments) > options.MaxComments {
+			comments = comments[len(comments)-options.MaxComments:]
+		}
+		issue.Comments = comments
+	}
+	if options.Timeline {
+		events, err := timelineProvider.Timeline(ctx, issue)
+		if err != nil {
+			return err
+		}
+		if len(options.EventTypes) > 0 {
+			var filtered []Event
+			for _, event := range events {
+				if containsFold(options.EventTypes, event.Type) {
+					filtered = append(filtered, event)
+				}
+			}
+			events = filtered
+		}
+		issue.Events = events
+	}
+	if options.LinkedPullRequests {
+		pullRequests, err := pullRequestsProvider.LinkedPullRequests(ctx, issue)
+		if err != nil {
+			return err
+		}
+		pullRequests = mergeLinkedPullRequests(pullRequests)
+		if options.ClosingPullRequestsOnly {
+			var filtered []LinkedPullRequest
+			for _, pr := range pullRequests {
+				if pr.Closes {
+					filtered = append(filtered, pr)
+				}
+			}
+			pullRequests = filtered
+		}
+		issue.LinkedPullRequests = pullRequests
+	}
+	if options.ReviewState && issue.IsPullRequest {
+		state, err := reviewStateProvider.ReviewState(ctx, issue)
+		if err != nil {
+			return err
+		}
+		issue.ReviewState = state
+	}
+	return nil
+}
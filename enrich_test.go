@@ -0,0 +1,262 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// fakeEnrichProvider is a fakeProvider that also lists comments and timeline
+// events, counting the concurrent requests
+type fakeEnrichProvider struct {
+	fakeProvider
+	mu       sync.Mutex
+	inFlight int
+	maxCalls int
+	fail     int
+}
+
+func (fp *fakeEnrichProvider) enter() {
+	fp.mu.Lock()
+	fp.inFlight++
+	if fp.inFlight > fp.maxCalls {
+		fp.maxCalls = fp.inFlight
+	}
+	fp.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+}
+
+func (fp *fakeEnrichProvider) leave() {
+	fp.mu.Lock()
+	fp.inFlight--
+	fp.mu.Unlock()
+}
+
+func (fp *fakeEnrichProvider) Comments(ctx context.Context, issue *issues2markdown.Issue) ([]issues2markdown.Comment, error) {
+	fp.enter()
+	defer fp.leave()
+	if issue.Number == fp.fail {
+		return nil, fmt.Errorf("comments unavailable")
+	}
+	var comments []issues2markdown.Comment
+	for i := 1; i <= issue.Number; i++ {
+		comments = append(comments, issues2markdown.Comment{
+			Author: issues2markdown.User{Login: "username"},
+			Body:   fmt.Sprintf("Comment %d", i),
+		})
+	}
+	return comments, nil
+}
+
+func (fp *fakeEnrichProvider) Timeline(ctx context.Context, issue *issues2markdown.Issue) ([]issues2markdown.Event, error) {
+	fp.enter()
+	defer fp.leave()
+	events := []issues2markdown.Event{
+		{Type: "labeled", Label: "bug"},
+		{Type: "subscribed"},
+		{Type: "closed"},
+	}
+	return events, nil
+}
+
+func (fp *fakeEnrichProvider) LinkedPullRequests(ctx context.Context, issue *issues2markdown.Issue) ([]issues2markdown.LinkedPullRequest, error) {
+	fp.enter()
+	defer fp.leave()
+	pullRequests := []issues2markdown.LinkedPullRequest{
+		{Number: 100 + issue.Number, Organization: "username", Repository: "repo", Title: "Mention"},
+		{Number: 200 + issue.Number, Organization: "username", Repository: "repo", Title: "Fix", Merged: true, Closes: true},
+		{Number: 100 + issue.Number, Organization: "username", Repository: "repo", Title: "Mention", Closes: true},
+		{Number: 300 + issue.Number, Organization: "username", Repository: "repo", Title: "Reference"},
+	}
+	return pullRequests, nil
+}
+
+func (fp *fakeEnrichProvider) ReviewState(ctx context.Context, issue *issues2markdown.Issue) (string, error) {
+	fp.enter()
+	defer fp.leave()
+	return issues2markdown.ReviewApproved, nil
+}
+
+func newFakeEnrichProvider(count int) *fakeEnrichProvider {
+	provider := &fakeEnrichProvider{}
+	provider.user = &issues2markdown.User{Login: "username"}
+	for i := 1; i <= count; i++ {
+		provider.issues = append(provider.issues, issues2markdown.Issue{Number: i, Title: fmt.Sprintf("Issue title %d", i)})
+	}
+	return provider
+}
+
+func TestEnrich(t *testing.T) {
+	provider := newFakeEnrichProvider(10)
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := issues2markdown.NewEnrichOptions()
+	options.Comments = true
+	options.MaxComments = 2
+	options.Timeline = true
+	options.EventTypes = []string{"labeled", "Closed"}
+	options.Concurrency = 3
+	if err := i2md.Enrich(issues, options); err != nil {
+		t.Fatal(err)
+	}
+	if provider.maxCalls > options.Concurrency {
+		t.Fatalf("Expected at most %d concurrent requests but got %d", options.Concurrency, provider.maxCalls)
+	}
+	for _, issue := range issues {
+		expectedComments := issue.Number
+		if expectedComments > 2 {
+			expectedComments = 2
+		}
+		if len(issue.Comments) != expectedComments {
+			t.Fatalf("Expected %d comments for #%d but got %d", expectedComments, issue.Number, len(issue.Comments))
+		}
+		if last := issue.Comments[len(issue.Comments)-1].Body; last != fmt.Sprintf("Comment %d", issue.Number) {
+			t.Fatalf("Expected the latest comments for #%d but got %q last", issue.Number, last)
+		}
+		if len(issue.Events) != 2 || issue.Events[0].Type != "labeled" || issue.Events[1].Type != "closed" {
+			t.Fatalf("Expected the labeled and closed events for #%d but got %+v", issue.Number, issue.Events)
+		}
+	}
+
+	render := issues2markdown.NewRenderOptions()
+	render.TemplateSource = `{{ range . }}- #{{ .Number }}{{ range .Comments }}
+  > {{ .Body }} ({{ .Author.Login }}){{ end }}{{ range .Events }}
+  * {{ .Type }}{{ with .Label }} {{ . }}{{ end }}{{ end }}
+{{ end }}`
+	markdown, err := i2md.Render(issues[1:2], render)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMarkdown := `- #2
+  > Comment 1 (username)
+  > Comment 2 (username)
+  * labeled bug
+  * closed`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestEnrichLinkedPullRequests(t *testing.T) {
+	provider := newFakeEnrichProvider(2)
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := []issues2markdown.Issue{{Number: 1}, {Number: 2}}
+	options := issues2markdown.NewEnrichOptions()
+	options.LinkedPullRequests = true
+	options.ClosingPullRequestsOnly = true
+	if err := i2md.Enrich(issues, options); err != nil {
+		t.Fatal(err)
+	}
+
+	render := issues2markdown.NewRenderOptions()
+	render.TemplateSource = `{{ range . }}- #{{ .Number }}{{ range .LinkedPullRequests }}
+  - [{{ if .Merged }}x{{ else }} {{ end }}] PR #{{ .Number }} {{ .Title }}{{ end }}
+{{ end }}`
+	markdown, err := i2md.Render(issues, render)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMarkdown := `- #1
+  - [ ] PR #101 Mention
+  - [x] PR #201 Fix
+- #2
+  - [ ] PR #102 Mention
+  - [x] PR #202 Fix`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestEnrichReviewState(t *testing.T) {
+	provider := newFakeEnrichProvider(2)
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := []issues2markdown.Issue{{Number: 1}, {Number: 2, IsPullRequest: true}}
+	options := issues2markdown.NewEnrichOptions()
+	options.ReviewState = true
+	if err := i2md.Enrich(issues, options); err != nil {
+		t.Fatal(err)
+	}
+	if issues[0].ReviewState != "" {
+		t.Fatalf("Expected no review state for an issue but got %q", issues[0].ReviewState)
+	}
+	if issues[1].ReviewState != issues2markdown.ReviewApproved {
+		t.Fatalf("Expected review state %q but got %q", issues2markdown.ReviewApproved, issues[1].ReviewState)
+	}
+}
+
+func TestEnrichNothing(t *testing.T) {
+	provider := &fakeProvider{user: &issues2markdown.User{Login: "username"}}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := []issues2markdown.Issue{{Number: 1}}
+	if err := i2md.Enrich(issues, issues2markdown.NewEnrichOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if issues[0].Comments != nil || issues[0].Events != nil {
+		t.Fatalf("Expected no comments and events but got %+v", issues[0])
+	}
+}
+
+func TestEnrichUnsupportedProvider(t *testing.T) {
+	provider := &fakeProvider{user: &issues2markdown.User{Login: "username"}}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewEnrichOptions()
+	options.Comments = true
+	if err := i2md.Enrich([]issues2markdown.Issue{{Number: 1}}, options); err == nil {
+		t.Fatal("Expected an error for a provider without comments")
+	}
+}
+
+func TestEnrichError(t *testing.T) {
+	provider := newFakeEnrichProvider(10)
+	provider.fail = 4
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewEnrichOptions()
+	options.Comments = true
+	err = i2md.Enrich(provider.issues, options)
+	if err == nil || err.Error() != "#4: comments unavailable" {
+		t.Fatalf("Expected the error of #4 but got %v", err)
+	}
+}
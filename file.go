@@ -0,0 +1,257 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// FileProvider is the IssueProvider for issues stored in a JSON file, so they
+// can be queried and rendered offline.
+//
+// The file contains a JSON array or newline delimited JSON values. Each value
+// is either an Issue, a Github issue or a Github search API response.
+type FileProvider struct {
+	Path  string
+	Login string
+}
+
+// NewFileProvider creates a FileProvider instance that reads the issues from
+// the file at path
+func NewFileProvider(path string) *FileProvider {
+	provider := &FileProvider{
+		Path: path,
+	}
+	return provider
+}
+
+// Authorize checks the file can be read and returns the configured Login as
+// the user
+func (fp *FileProvider) Authorize(ctx context.Context) (*User, error) {
+	if _, err := os.Stat(fp.Path); err != nil {
+		return nil, err
+	}
+	result := &User{
+		Login: fp.Login,
+	}
+	return result, nil
+}
+
+// Search returns the Issues in the file that match the query
+//
+// The is:, state:, type:, repo:, org:, user:, author:, assignee: and label:
+// qualifiers and the free text terms, matched against the title, are
+// supported. All the Issues are returned in a single page.
+func (fp *FileProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	data, err := ioutil.ReadFile(fp.Path)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := decodeFileIssues(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fp.Path, err)
+	}
+
+	filter, err := newLocalIssueFilter("file", query)
+	if err != nil {
+		return nil, err
+	}
+	result := &SearchResult{}
+	for _, v := range issues {
+		if filter(&v) {
+			result.Issues = append(result.Issues, v)
+		}
+	}
+	return result, nil
+}
+
+// decodeFileIssues decodes the issues of a JSON array or a stream of JSON
+// values
+func decodeFileIssues(data []byte) ([]Issue, error) {
+	var values []json.RawMessage
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var value json.RawMessage
+			err := decoder.Decode(&value)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+
+	var result []Issue
+	for _, value := range values {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(value, &fields); err != nil {
+			return nil, err
+		}
+
+		// a Github search API response
+		if items, ok := fields["items"]; ok {
+			var page []json.RawMessage
+			if err := json.Unmarshal(items, &page); err != nil {
+				return nil, err
+			}
+			for _, item := range page {
+				issue, err := decodeGithubIssue(item)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, issue)
+			}
+			continue
+		}
+
+		// a Github issue
+		_, hasHTMLURL := fields["html_url"]
+		_, hasRepositoryURL := fields["repository_url"]
+		if hasHTMLURL || hasRepositoryURL {
+			issue, err := decodeGithubIssue(value)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, issue)
+			continue
+		}
+
+		// an Issue
+		issue := Issue{}
+		if err := json.Unmarshal(value, &issue); err != nil {
+			return nil, err
+		}
+		result = append(result, issue)
+	}
+	return result, nil
+}
+
+// newLocalIssueFilter creates a function that reports whether an issue matches
+// the query, for the providers that filter the issues themselves
+func newLocalIssueFilter(provider string, query string) (func(*Issue) bool, error) {
+	terms := parseSearchTerms(query)
+
+	state := terms.state()
+	terms.remove("is", "open", "closed")
+	terms.remove("state", "open", "closed")
+
+	pullRequest := ""
+	for _, key := range []string{"is", "type"} {
+		for _, v := range terms.qualifiers[key] {
+			switch strings.ToLower(v) {
+			case "issue":
+				pullRequest = "false"
+			case "pr":
+				pullRequest = "true"
+			}
+		}
+		terms.remove(key, "issue", "pr")
+	}
+
+	repos := terms.qualifiers["repo"]
+	owners := append(terms.qualifiers["org"], terms.qualifiers["user"]...)
+	authors := terms.qualifiers["author"]
+	assignees := terms.qualifiers["assignee"]
+	labels := terms.qualifiers["label"]
+	excludedLabels := terms.qualifiers["-label"]
+	for _, key := range []string{"repo", "org", "user", "author", "assignee", "label", "-label"} {
+		terms.remove(key)
+	}
+
+	// there is no archived information in the file
+	terms.remove("archived", "false")
+	if err := terms.unsupported(provider); err != nil {
+		return nil, err
+	}
+	text := terms.text
+
+	filter := func(v *Issue) bool {
+		if state != "" && !strings.EqualFold(v.State, state) {
+			return false
+		}
+		if pullRequest != "" && pullRequest != fmt.Sprint(v.IsPullRequest) {
+			return false
+		}
+		organization, _ := v.GetOrganization()
+		repository, _ := v.GetRepository()
+		if len(repos) > 0 && !containsFold(repos, organization+"/"+repository) {
+			return false
+		}
+		if len(owners) > 0 && !containsFold(owners, organization) {
+			return false
+		}
+		if len(authors) > 0 && !containsFold(authors, v.Author.Login) {
+			return false
+		}
+		var issueAssignees, issueLabels []string
+		for _, assignee := range v.Assignees {
+			issueAssignees = append(issueAssignees, assignee.Login)
+		}
+		for _, label := range v.Labels {
+			issueLabels = append(issueLabels, label.Name)
+		}
+		for _, assignee := range assignees {
+			if !containsFold(issueAssignees, assignee) {
+				return false
+			}
+		}
+		for _, label := range labels {
+			if !containsFold(issueLabels, label) {
+				return false
+			}
+		}
+		for _, label := range excludedLabels {
+			if containsFold(issueLabels, label) {
+				return false
+			}
+		}
+		title := strings.ToLower(v.Title)
+		for _, word := range text {
+			if !strings.Contains(title, strings.ToLower(word)) {
+				return false
+			}
+		}
+		return true
+	}
+	return filter, nil
+}
+
+// containsFold reports whether the value is in the list, compared without
+// case
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+func TestFileProviderAuthorize(t *testing.T) {
+	provider := issues2markdown.NewFileProvider("testdata/issues.ndjson")
+	provider.Login = "username"
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "username" {
+		t.Fatalf("Expected login %q but got %q", "username", user.Login)
+	}
+}
+
+func TestFileProviderAuthorizeMissingFile(t *testing.T) {
+	provider := issues2markdown.NewFileProvider("testdata/missing.json")
+	_, err := provider.Authorize(context.Background())
+	if err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+}
+
+func TestFileProviderSearch(t *testing.T) {
+	tests := []struct {
+		path    string
+		query   string
+		numbers []int
+	}{
+		{"testdata/search_issues.json", "", []int{1, 2, 3, 4}},
+		{"testdata/search_issues.json", "type:issue", []int{1, 2}},
+		{"testdata/search_issues.json", "is:pr", []int{3, 4}},
+		{"testdata/search_issues.json", "is:pr is:closed", []int{4}},
+		{"testdata/search_issues.json", "type:issue is:open author:username archived:false", []int{1}},
+		{"testdata/search_issues.json", "is:closed repo:username/repo", []int{2}},
+		{"testdata/search_issues.json", "org:octocat", []int{3, 4}},
+		{"testdata/search_issues.json", "label:bug assignee:octocat", []int{1}},
+		{"testdata/search_issues.json", "-label:bug", []int{2, 3, 4}},
+		{"testdata/search_issues.json", "title 2", []int{2}},
+		{"testdata/issues.ndjson", "type:issue", []int{1, 2, 3}},
+		{"testdata/issues.ndjson", "is:open", []int{1, 3}},
+		{"testdata/issues.ndjson", "user:group", []int{2}},
+		{"testdata/issues.ndjson", "author:octocat", []int{3}},
+	}
+	for _, test := range tests {
+		provider := issues2markdown.NewFileProvider(test.path)
+		result, err := provider.Search(context.Background(), test.query, &issues2markdown.SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var numbers []int
+		for _, issue := range result.Issues {
+			numbers = append(numbers, issue.Number)
+		}
+		if len(numbers) != len(test.numbers) {
+			t.Fatalf("Expected issues %v for query %q in %s but got %v", test.numbers, test.query, test.path, numbers)
+		}
+		for i := range numbers {
+			if numbers[i] != test.numbers[i] {
+				t.Fatalf("Expected issues %v for query %q in %s but got %v", test.numbers, test.query, test.path, numbers)
+			}
+		}
+		if result.NextPage != "" {
+			t.Fatalf("Expected a single page but got next page %q", result.NextPage)
+		}
+	}
+}
+
+func TestFileProviderSearchUnsupportedQualifier(t *testing.T) {
+	provider := issues2markdown.NewFileProvider("testdata/issues.ndjson")
+	_, err := provider.Search(context.Background(), "milestone:v1.0", &issues2markdown.SearchOptions{})
+	if err == nil {
+		t.Fatal("Expected an unsupported qualifier error")
+	}
+}
+
+func TestFileProviderSearchParenthesesInText(t *testing.T) {
+	provider := issues2markdown.NewFileProvider("testdata/issues.ndjson")
+	query := "fix(parser)"
+	if _, err := issues2markdown.ParseSearchQuery(query); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Search(context.Background(), query, &issues2markdown.SearchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileProviderSearchUnsupportedOperators(t *testing.T) {
+	provider := issues2markdown.NewFileProvider("testdata/issues.ndjson")
+	queries := []string{
+		"is:open NOT wontfix",
+		"label:a OR label:b",
+		issues2markdown.NewSearchQuery(issues2markdown.Or(issues2markdown.LabelQualifier("a"), issues2markdown.LabelQualifier("b"))).String(),
+	}
+	for _, query := range queries {
+		_, err := provider.Search(context.Background(), query, &issues2markdown.SearchOptions{})
+		if err == nil || !strings.Contains(err.Error(), "unsupported search operator") {
+			t.Fatalf("Expected an unsupported operator error for %q but got %v", query, err)
+		}
+	}
+}
+
+func TestFileProviderSearchPullRequests(t *testing.T) {
+	provider := issues2markdown.NewFileProvider("testdata/search_issues.json")
+	result, err := provider.Search(context.Background(), "", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		pullRequest bool
+		draft       bool
+		merged      bool
+	}{
+		{false, false, false},
+		{false, false, false},
+		{true, true, false},
+		{true, false, true},
+	}
+	if len(result.Issues) != len(expected) {
+		t.Fatalf("Expected %d issues but got %d", len(expected), len(result.Issues))
+	}
+	for i, issue := range result.Issues {
+		if issue.IsPullRequest != expected[i].pullRequest || issue.Draft != expected[i].draft || issue.Merged != expected[i].merged {
+			t.Fatalf("Expected #%d pull request %v, draft %v and merged %v but got %v, %v and %v", issue.Number,
+				expected[i].pullRequest, expected[i].draft, expected[i].merged, issue.IsPullRequest, issue.Draft, issue.Merged)
+		}
+	}
+}
+
+func TestFileProviderQueryRender(t *testing.T) {
+	provider := issues2markdown.NewFileProvider("testdata/issues.ndjson")
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "is:open")
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := i2md.Render(issues, issues2markdown.NewRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMarkdown := `- [ ] username/repo : [#1 Issue title 1](https://github.com/username/repo/issues/1)
+- [ ] octocat/Hello-World : [#3 Issue title 3](https://github.com/octocat/Hello-World/issues/3)`
+
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
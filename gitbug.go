@@ -0,0 +1,291 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// git-bug operation types
+const (
+	gitBugCreateOp      = 1
+	gitBugSetTitleOp    = 2
+	gitBugAddCommentOp  = 3
+	gitBugSetStatusOp   = 4
+	gitBugLabelChangeOp = 5
+)
+
+// git-bug statuses
+const (
+	gitBugOpenStatus   = 1
+	gitBugClosedStatus = 2
+)
+
+// GitBugProvider is the IssueProvider for the issues stored inside a local
+// git repository by git-bug, as refs/bugs/ references to chains of commits
+// of operations.
+//
+// The git command is used to read the repository.
+type GitBugProvider struct {
+	Path string
+	// WebURL is the base URL of the git-bug web interface used for the HTML
+	// URL of the Issues. The repository file URL is used if empty.
+	WebURL string
+}
+
+// NewGitBugProvider creates a GitBugProvider instance that reads the issues
+// from the git repository at path
+func NewGitBugProvider(path string) *GitBugProvider {
+	provider := &GitBugProvider{
+		Path: path,
+	}
+	return provider
+}
+
+// gitBugAuthor represents a git-bug author, either a reference to an
+// identity or, in older repositories, the embedded identity
+type gitBugAuthor struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Login string `json:"login"`
+}
+
+// gitBugOperationPack represents the operations stored in a commit
+type gitBugOperationPack struct {
+	Author     *gitBugAuthor     `json:"author"`
+	Operations []gitBugOperation `json:"ops"`
+}
+
+// gitBugOperation represents a git-bug operation
+type gitBugOperation struct {
+	Type      int           `json:"type"`
+	Author    *gitBugAuthor `json:"author"`
+	Timestamp int64         `json:"timestamp"`
+	Title     string        `json:"title"`
+	Message   string        `json:"message"`
+	Status    int           `json:"status"`
+	Added     []string      `json:"added"`
+	Removed   []string      `json:"removed"`
+}
+
+// gitBugBug is the state of a bug after applying its operations
+type gitBugBug struct {
+	id    string
+	issue Issue
+}
+
+// Authorize checks the repository can be read and returns the git-bug
+// identity of the repository user, or the git user name if there is none
+func (gp *GitBugProvider) Authorize(ctx context.Context) (*User, error) {
+	if _, err := gp.git(ctx, "rev-parse", "--git-dir"); err != nil {
+		return nil, err
+	}
+	result := &User{}
+	if id, err := gp.git(ctx, "config", "--get", "git-bug.identity"); err == nil {
+		author, err := gp.resolveAuthor(ctx, &gitBugAuthor{ID: strings.TrimSpace(string(id))}, nil)
+		if err != nil {
+			return nil, err
+		}
+		result.Login = author
+	}
+	if result.Login == "" {
+		name, _ := gp.git(ctx, "config", "--get", "user.name")
+		result.Login = strings.TrimSpace(string(name))
+	}
+	return result, nil
+}
+
+// Search returns the Issues in the repository that match the query
+//
+// The is:, state:, repo:, org:, user:, author: and label: qualifiers and the
+// free text terms, matched against the title, are supported. All the Issues
+// are returned in a single page, numbered by creation order.
+func (gp *GitBugProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	filter, err := newLocalIssueFilter("git-bug", query)
+	if err != nil {
+		return nil, err
+	}
+	path, err := filepath.Abs(gp.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := gp.git(ctx, "for-each-ref", "--format=%(refname)", "refs/bugs/")
+	if err != nil {
+		return nil, err
+	}
+	identities := make(map[string]string)
+	var bugs []gitBugBug
+	for _, ref := range strings.Fields(string(refs)) {
+		bug, err := gp.readBug(ctx, ref, identities)
+		if err != nil {
+			return nil, err
+		}
+		bugs = append(bugs, bug)
+	}
+	sort.SliceStable(bugs, func(i, j int) bool {
+		if !bugs[i].issue.CreatedAt.Equal(bugs[j].issue.CreatedAt) {
+			return bugs[i].issue.CreatedAt.Before(bugs[j].issue.CreatedAt)
+		}
+		return bugs[i].id < bugs[j].id
+	})
+
+	result := &SearchResult{}
+	for i, bug := range bugs {
+		bug.issue.Number = i + 1
+		bug.issue.Organization = filepath.Base(filepath.Dir(path))
+		bug.issue.Repository = filepath.Base(path)
+		bug.issue.URL = "file://" + filepath.ToSlash(path) + "#" + bug.id
+		bug.issue.HTMLURL = bug.issue.URL
+		if gp.WebURL != "" {
+			bug.issue.HTMLURL = strings.TrimSuffix(gp.WebURL, "/") + "/bug/" + bug.id
+		}
+		if filter(&bug.issue) {
+			result.Issues = append(result.Issues, bug.issue)
+		}
+	}
+	return result, nil
+}
+
+// readBug applies the operations of all the commits of a bug reference
+func (gp *GitBugProvider) readBug(ctx context.Context, ref string, identities map[string]string) (gitBugBug, error) {
+	bug := gitBugBug{
+		id: strings.TrimPrefix(ref, "refs/bugs/"),
+	}
+	bug.issue.State = "open"
+
+	commits, err := gp.git(ctx, "rev-list", "--reverse", "--topo-order", ref)
+	if err != nil {
+		return bug, err
+	}
+	labels := make(map[string]bool)
+	for _, commit := range strings.Fields(string(commits)) {
+		data, err := gp.git(ctx, "cat-file", "blob", commit+":ops")
+		if err != nil {
+			return bug, err
+		}
+		pack := &gitBugOperationPack{}
+		if err := json.Unmarshal(data, pack); err != nil {
+			return bug, fmt.Errorf("git-bug: %s: %v", commit, err)
+		}
+		for _, op := range pack.Operations {
+			// the latest operation is the last update
+			if updatedAt := time.Unix(op.Timestamp, 0).UTC(); updatedAt.After(bug.issue.UpdatedAt) {
+				bug.issue.UpdatedAt = updatedAt
+			}
+			switch op.Type {
+			case gitBugCreateOp:
+				bug.issue.Title = op.Title
+				bug.issue.Body = op.Message
+				bug.issue.CreatedAt = time.Unix(op.Timestamp, 0).UTC()
+				bug.issue.Author.Login, err = gp.resolveAuthor(ctx, op.Author, identities)
+				if err != nil {
+					return bug, err
+				}
+				if bug.issue.Author.Login == "" {
+					bug.issue.Author.Login, err = gp.resolveAuthor(ctx, pack.Author, identities)
+					if err != nil {
+						return bug, err
+					}
+				}
+			case gitBugSetTitleOp:
+				bug.issue.Title = op.Title
+			case gitBugAddCommentOp:
+				bug.issue.CommentsCount++
+			case gitBugSetStatusOp:
+				switch op.Status {
+				case gitBugOpenStatus:
+					bug.issue.State = "open"
+					bug.issue.ClosedAt = nil
+				case gitBugClosedStatus:
+					closedAt := time.Unix(op.Timestamp, 0).UTC()
+					bug.issue.State = "closed"
+					bug.issue.ClosedAt = &closedAt
+				}
+			case gitBugLabelChangeOp:
+				for _, label := range op.Added {
+					labels[label] = true
+				}
+				for _, label := range op.Removed {
+					delete(labels, label)
+				}
+			}
+		}
+	}
+	var names []string
+	for label := range labels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		bug.issue.Labels = append(bug.issue.Labels, Label{Name: name})
+	}
+	return bug, nil
+}
+
+// resolveAuthor returns the login, or the name if there is no login, of an
+// author. Identity references are read from refs/identities/ and cached.
+func (gp *GitBugProvider) resolveAuthor(ctx context.Context, author *gitBugAuthor, identities map[string]string) (string, error) {
+	if author == nil {
+		return "", nil
+	}
+	if author.ID == "" {
+		if author.Login != "" {
+			return author.Login, nil
+		}
+		return author.Name, nil
+	}
+	if login, ok := identities[author.ID]; ok {
+		return login, nil
+	}
+
+	data, err := gp.git(ctx, "cat-file", "blob", "refs/identities/"+author.ID+":version")
+	if err != nil {
+		return "", err
+	}
+	identity := &gitBugAuthor{}
+	if err := json.Unmarshal(data, identity); err != nil {
+		return "", fmt.Errorf("git-bug: identity %s: %v", author.ID, err)
+	}
+	login, _ := gp.resolveAuthor(ctx, identity, nil)
+	if identities != nil {
+		identities[author.ID] = login
+	}
+	return login, nil
+}
+
+// git runs a git command in the repository and returns its output
+func (gp *GitBugProvider) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", gp.Path}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
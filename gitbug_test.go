@@ -0,0 +1,200 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// gitBugSetup creates a git repository with git-bug bugs and identities
+func gitBugSetup(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	path := filepath.Join(t.TempDir(), "org", "repo")
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git := func(input string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", path}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		cmd.Stdin = strings.NewReader(input)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	commit := func(name, data string, parents ...string) string {
+		blob := git(data, "hash-object", "-w", "--stdin")
+		tree := git("100644 blob "+blob+"\t"+name+"\n", "mktree")
+		args := []string{"commit-tree", tree, "-m", name}
+		for _, parent := range parents {
+			args = append(args, "-p", parent)
+		}
+		return git("", args...)
+	}
+
+	git("", "init", "-q")
+	git("", "config", "user.name", "git user")
+	identity := commit("version", `{"name":"User Name","login":"username"}`)
+	git("", "update-ref", "refs/identities/abc123", identity)
+
+	first := commit("ops", `{"ops":[{"type":1,"author":{"id":"abc123"},"timestamp":100,"title":"First bug","message":""}]}`)
+	first = commit("ops", `{"ops":[{"type":5,"author":{"id":"abc123"},"timestamp":110,"added":["bug","ui"]},{"type":5,"author":{"id":"abc123"},"timestamp":120,"removed":["ui"]}]}`, first)
+	git("", "update-ref", "refs/bugs/f1rst", first)
+
+	second := commit("ops", `{"author":{"name":"Octo Cat","login":"octocat"},"ops":[{"type":1,"timestamp":200,"title":"Second bug","message":""}]}`)
+	second = commit("ops", `{"ops":[{"type":2,"timestamp":210,"title":"Renamed bug"},{"type":4,"timestamp":220,"status":2}]}`, second)
+	git("", "update-ref", "refs/bugs/s3cond", second)
+	return path
+}
+
+func TestGitBugProviderAuthorize(t *testing.T) {
+	path := gitBugSetup(t)
+	provider := issues2markdown.NewGitBugProvider(path)
+
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "git user" {
+		t.Fatalf("Expected login %q but got %q", "git user", user.Login)
+	}
+
+	if err := exec.Command("git", "-C", path, "config", "git-bug.identity", "abc123").Run(); err != nil {
+		t.Fatal(err)
+	}
+	user, err = provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "username" {
+		t.Fatalf("Expected login %q but got %q", "username", user.Login)
+	}
+}
+
+func TestGitBugProviderAuthorizeNotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	provider := issues2markdown.NewGitBugProvider(t.TempDir())
+	if _, err := provider.Authorize(context.Background()); err == nil {
+		t.Fatal("Expected an error for a directory that is not a repository")
+	}
+}
+
+func TestGitBugProviderSearch(t *testing.T) {
+	path := gitBugSetup(t)
+	provider := issues2markdown.NewGitBugProvider(path)
+	provider.WebURL = "http://localhost:8080/"
+
+	result, err := provider.Search(context.Background(), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NextPage != "" {
+		t.Fatalf("Expected a single page but got next page %q", result.NextPage)
+	}
+	closedAt := time.Unix(220, 0).UTC()
+	expected := []issues2markdown.Issue{
+		{
+			Number:       1,
+			Title:        "First bug",
+			State:        "open",
+			HTMLURL:      "http://localhost:8080/bug/f1rst",
+			Organization: "org",
+			Repository:   "repo",
+			Author:       issues2markdown.User{Login: "username"},
+			Labels:       []issues2markdown.Label{{Name: "bug"}},
+			CreatedAt:    time.Unix(100, 0).UTC(),
+			UpdatedAt:    time.Unix(120, 0).UTC(),
+		},
+		{
+			Number:       2,
+			Title:        "Renamed bug",
+			State:        "closed",
+			HTMLURL:      "http://localhost:8080/bug/s3cond",
+			Organization: "org",
+			Repository:   "repo",
+			Author:       issues2markdown.User{Login: "octocat"},
+			CreatedAt:    time.Unix(200, 0).UTC(),
+			UpdatedAt:    time.Unix(220, 0).UTC(),
+			ClosedAt:     &closedAt,
+		},
+	}
+	for i := range result.Issues {
+		if !strings.HasPrefix(result.Issues[i].URL, "file://") {
+			t.Fatalf("Expected a file URL but got %q", result.Issues[i].URL)
+		}
+		result.Issues[i].URL = ""
+	}
+	if !reflect.DeepEqual(result.Issues, expected) {
+		t.Fatalf("Expected issues %#v but got %#v", expected, result.Issues)
+	}
+}
+
+func TestGitBugProviderSearchQualifiers(t *testing.T) {
+	path := gitBugSetup(t)
+	provider := issues2markdown.NewGitBugProvider(path)
+
+	tests := []struct {
+		query   string
+		numbers []int
+	}{
+		{"type:issue is:open author:username archived:false", []int{1}},
+		{"is:closed", []int{2}},
+		{"author:octocat", []int{2}},
+		{"label:bug", []int{1}},
+		{"label:ui", nil},
+		{"repo:org/repo renamed", []int{2}},
+		{"repo:org/other", nil},
+	}
+	for _, test := range tests {
+		result, err := provider.Search(context.Background(), test.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var numbers []int
+		for _, issue := range result.Issues {
+			numbers = append(numbers, issue.Number)
+		}
+		if !reflect.DeepEqual(numbers, test.numbers) {
+			t.Fatalf("Query %q: expected issues %v but got %v", test.query, test.numbers, numbers)
+		}
+	}
+}
+
+func TestGitBugProviderSearchUnsupportedQualifier(t *testing.T) {
+	path := gitBugSetup(t)
+	provider := issues2markdown.NewGitBugProvider(path)
+	if _, err := provider.Search(context.Background(), "milestone:v1", nil); err == nil {
+		t.Fatal("Expected an error for an unsupported qualifier")
+	}
+}
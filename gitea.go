@@ -0,0 +1,374 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// giteaPageSize is the number of issues requested per page
+	giteaPageSize = 50
+)
+
+// GiteaProvider is the IssueProvider for the Gitea and Forgejo REST API
+type GiteaProvider struct {
+	client  *http.Client
+	BaseURL *url.URL
+	Token   string
+	login   string
+}
+
+// NewGiteaProvider creates a GiteaProvider instance for the Gitea instance at
+// baseURL authenticated with an access token.
+//
+// If the provided httpClient is nil http.DefaultClient is used. When the
+// httpClient already handles the authentication the token can be empty.
+func NewGiteaProvider(httpClient *http.Client, baseURL string, token string) (*GiteaProvider, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("gitea: a base URL is required")
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	parsedBaseURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	provider := &GiteaProvider{
+		client:  httpClient,
+		BaseURL: parsedBaseURL,
+		Token:   token,
+	}
+	return provider, nil
+}
+
+// giteaUser represents a Gitea user
+type giteaUser struct {
+	Login string `json:"login"`
+}
+
+// giteaIssue represents a Gitea issue
+type giteaIssue struct {
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	State      string `json:"state"`
+	URL        string `json:"url"`
+	HTMLURL    string `json:"html_url"`
+	Repository struct {
+		Owner    string `json:"owner"`
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	User      giteaUser   `json:"user"`
+	Assignees []giteaUser `json:"assignees"`
+	Labels    []struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title string     `json:"title"`
+		DueOn *time.Time `json:"due_on"`
+	} `json:"milestone"`
+	Body      string     `json:"body"`
+	Comments  int        `json:"comments"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	// PullRequest is only set for pull requests
+	PullRequest *struct {
+		Merged bool `json:"merged"`
+		Draft  bool `json:"draft"`
+	} `json:"pull_request"`
+}
+
+// Authorize gets authentication information
+func (gp *GiteaProvider) Authorize(ctx context.Context) (*User, error) {
+	req, err := gp.newRequest("api/v1/user")
+	if err != nil {
+		return nil, err
+	}
+	user := &giteaUser{}
+	_, err = doJSON(ctx, gp.client, req, user)
+	if err != nil {
+		return nil, err
+	}
+	gp.login = user.Login
+	result := &User{
+		Login: user.Login,
+	}
+	return result, nil
+}
+
+// Search returns a page of the Issues that match the query
+//
+// The query qualifiers are translated to the parameters of the Gitea issues
+// search API. The repo: qualifier lists the issues of a repository and the
+// org: and user: qualifiers the issues of the repositories of an owner. The
+// is:, state:, type:, label:, milestone: and free text terms filter the list.
+//
+// Gitea only filters the issues search by author, assignee or mentions for
+// the authenticated user, other users are supported with the repo: qualifier.
+func (gp *GiteaProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	var req *http.Request
+	var err error
+	if strings.HasPrefix(options.Page, gp.BaseURL.String()) {
+		// the page is the next link of a previous response
+		req, err = gp.newRequest(options.Page)
+	} else {
+		req, err = gp.newSearchRequest(ctx, query, options.Page)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []giteaIssue
+	response, err := doJSON(ctx, gp.client, req, &issues)
+	if err != nil {
+		return nil, err
+	}
+
+	// process page results
+	result := &SearchResult{}
+	for _, v := range issues {
+		result.Issues = append(result.Issues, newIssueFromGitea(&v))
+	}
+
+	// process pagination
+	result.NextPage = nextLink(response)
+	if result.NextPage == "" && len(issues) == giteaPageSize {
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		result.NextPage = strconv.Itoa(page + 1)
+	}
+	return result, nil
+}
+
+// newRequest creates a GET request for the API path, relative to BaseURL
+func (gp *GiteaProvider) newRequest(path string) (*http.Request, error) {
+	u, err := gp.BaseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if gp.Token != "" {
+		req.Header.Set("Authorization", "token "+gp.Token)
+	}
+	return req, nil
+}
+
+// newSearchRequest creates the issues search request that matches the query
+func (gp *GiteaProvider) newSearchRequest(ctx context.Context, query string, page string) (*http.Request, error) {
+	terms := parseSearchTerms(query)
+	params := url.Values{}
+
+	// Gitea doesn't support the type: qualifier but a type parameter, both
+	// issues and pull requests are returned without it
+	for _, key := range []string{"type", "is"} {
+		for _, v := range terms.qualifiers[key] {
+			switch strings.ToLower(v) {
+			case "issue":
+				params.Set("type", "issues")
+			case "pr":
+				params.Set("type", "pulls")
+			}
+		}
+		terms.remove(key, "issue", "pr")
+	}
+
+	// Gitea only returns open issues unless a state is requested
+	params.Set("state", "all")
+	if state := terms.state(); state != "" {
+		params.Set("state", state)
+	}
+	terms.remove("is", "open", "closed")
+	terms.remove("state", "open", "closed")
+
+	if labels := terms.qualifiers["label"]; len(labels) > 0 {
+		params.Set("labels", strings.Join(labels, ","))
+	}
+	terms.remove("label")
+	if milestones := terms.qualifiers["milestone"]; len(milestones) > 0 {
+		params.Set("milestones", strings.Join(milestones, ","))
+	}
+	terms.remove("milestone")
+	if len(terms.text) > 0 {
+		params.Set("q", strings.Join(terms.text, " "))
+	}
+
+	path := "api/v1/repos/issues/search"
+	if repo := terms.get("repo"); repo != "" {
+		// the repository issues list filters by any user
+		parts := strings.SplitN(repo, "/", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("gitea: invalid repository %q", repo)
+		}
+		path = "api/v1/repos/" + url.PathEscape(parts[0]) + "/" + url.PathEscape(parts[1]) + "/issues"
+		userParams := map[string]string{
+			"author":   "created_by",
+			"assignee": "assigned_by",
+			"mentions": "mentioned_by",
+		}
+		for key, param := range userParams {
+			if user := terms.get(key); user != "" {
+				params.Set(param, user)
+			}
+			terms.remove(key)
+		}
+	} else {
+		if owner := terms.get("org"); owner != "" {
+			params.Set("owner", owner)
+		} else if owner := terms.get("user"); owner != "" {
+			params.Set("owner", owner)
+		}
+		terms.remove("org")
+		terms.remove("user")
+
+		// the issues search only filters by the authenticated user
+		userParams := map[string]string{
+			"author":   "created",
+			"assignee": "assigned",
+			"mentions": "mentioned",
+		}
+		for key, param := range userParams {
+			user := terms.get(key)
+			if user == "" {
+				continue
+			}
+			if gp.login == "" {
+				if _, err := gp.Authorize(ctx); err != nil {
+					return nil, err
+				}
+			}
+			if !strings.EqualFold(user, gp.login) {
+				continue
+			}
+			params.Set(param, "true")
+			terms.remove(key)
+		}
+	}
+	terms.remove("repo")
+
+	// Gitea can't filter archived repositories, the qualifier is ignored
+	terms.remove("archived", "false")
+	if err := terms.unsupported("gitea"); err != nil {
+		return nil, err
+	}
+
+	params.Set("limit", strconv.Itoa(giteaPageSize))
+	if page != "" {
+		if _, err := strconv.Atoi(page); err != nil {
+			return nil, err
+		}
+		params.Set("page", page)
+	}
+
+	req, err := gp.newRequest(path)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = params.Encode()
+	return req, nil
+}
+
+// newIssueFromGitea creates an Issue from a Gitea issue
+func newIssueFromGitea(v *giteaIssue) Issue {
+	item := Issue{
+		Number:       v.Number,
+		Title:        v.Title,
+		State:        v.State,
+		URL:          v.URL,
+		HTMLURL:      v.HTMLURL,
+		Organization: v.Repository.Owner,
+		Repository:   v.Repository.Name,
+		Author: User{
+			Login: v.User.Login,
+		},
+		Body:          v.Body,
+		CommentsCount: v.Comments,
+		CreatedAt:     v.CreatedAt,
+		UpdatedAt:     v.UpdatedAt,
+		ClosedAt:      v.ClosedAt,
+	}
+	if v.PullRequest != nil {
+		item.IsPullRequest = true
+		item.Merged = v.PullRequest.Merged
+		item.Draft = v.PullRequest.Draft
+	}
+	for _, assignee := range v.Assignees {
+		item.Assignees = append(item.Assignees, User{Login: assignee.Login})
+	}
+	for _, label := range v.Labels {
+		item.Labels = append(item.Labels, Label{Name: label.Name, Color: strings.TrimPrefix(label.Color, "#")})
+	}
+	if v.Milestone != nil {
+		item.Milestone = &Milestone{
+			Title: v.Milestone.Title,
+			DueOn: v.Milestone.DueOn,
+		}
+	}
+	return item
+}
+
+// giteaComment represents a Gitea issue comment
+type giteaComment struct {
+	Body      string    `json:"body"`
+	User      giteaUser `json:"user"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Comments returns all the comments of the Issue, oldest first
+func (gp *GiteaProvider) Comments(ctx context.Context, issue *Issue) ([]Comment, error) {
+	organization, repository, err := issueRepository(issue)
+	if err != nil {
+		return nil, err
+	}
+	req, err := gp.newRequest(fmt.Sprintf("api/v1/repos/%s/%s/issues/%d/comments", url.PathEscape(organization), url.PathEscape(repository), issue.Number))
+	if err != nil {
+		return nil, err
+	}
+	var comments []giteaComment
+	if _, err := doJSON(ctx, gp.client, req, &comments); err != nil {
+		return nil, err
+	}
+	var result []Comment
+	for _, v := range comments {
+		result = append(result, Comment{
+			Author:    User{Login: v.User.Login},
+			Body:      v.Body,
+			HTMLURL:   v.HTMLURL,
+			CreatedAt: v.CreatedAt,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// giteaSetup sets up a test HTTP server along with a GiteaProvider that is
+// configured to talk to that test server.
+func giteaSetup(t *testing.T) (provider *issues2markdown.GiteaProvider, mux *http.ServeMux, serverURL string, teardown func()) {
+	mux = http.NewServeMux()
+	mux.HandleFunc("/api/v1/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.Header.Get("Authorization"); got != "token gitea_token" {
+			t.Errorf("Expected authorization %q but got %q", "token gitea_token", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"login": "username"}`)
+	})
+	server := httptest.NewServer(mux)
+	provider, err := issues2markdown.NewGiteaProvider(nil, server.URL, "gitea_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, mux, server.URL, server.Close
+}
+
+func TestGiteaProviderAuthorize(t *testing.T) {
+	provider, _, _, teardown := giteaSetup(t)
+	defer teardown()
+
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "username" {
+		t.Fatalf("Expected login %q but got %q", "username", user.Login)
+	}
+}
+
+func TestGiteaProviderSearchDefaultQuery(t *testing.T) {
+	provider, mux, serverURL, teardown := giteaSetup(t)
+	mux.HandleFunc("/api/v1/repos/issues/search", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		expected := map[string]string{
+			"type":    "issues",
+			"state":   "open",
+			"created": "true",
+			"owner":   "",
+			"q":       "",
+		}
+		for k, v := range expected {
+			if got := r.URL.Query().Get(k); got != v {
+				t.Errorf("Expected parameter %s=%q but got %q", k, v, got)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			next := fmt.Sprintf("%s/api/v1/repos/issues/search?%s&page=2", serverURL, r.URL.RawQuery)
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next",<%s>; rel="last"`, next, next))
+		}
+		fmt.Fprint(w, `[{"number": 1, "title": "Issue title 1", "state": "open", "url": "https://gitea.example.com/api/v1/repos/username/repo/issues/1", "html_url": "https://gitea.example.com/username/repo/issues/1", "repository": {"owner": "username", "name": "repo", "full_name": "username/repo"}}]`)
+	})
+	defer teardown()
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	options.Organization = "username"
+	issues, err := i2md.Query(options, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("Expected %d issues but got %d", 2, len(issues))
+	}
+
+	markdown, err := i2md.Render(issues[:1], issues2markdown.NewRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMarkdown := `- [ ] username/repo : [#1 Issue title 1](https://gitea.example.com/username/repo/issues/1)`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestGiteaProviderSearchRepository(t *testing.T) {
+	provider, mux, _, teardown := giteaSetup(t)
+	mux.HandleFunc("/api/v1/repos/organization/repository/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		expected := map[string]string{
+			"type":       "issues",
+			"state":      "all",
+			"created_by": "someone",
+			"labels":     "bug",
+			"q":          "crash",
+		}
+		for k, v := range expected {
+			if got := r.URL.Query().Get(k); got != v {
+				t.Errorf("Expected parameter %s=%q but got %q", k, v, got)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[]`)
+	})
+	defer teardown()
+
+	query := "type:issue repo:organization/repository author:someone label:bug crash"
+	result, err := provider.Search(context.Background(), query, &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 0 || result.NextPage != "" {
+		t.Fatalf("Expected an empty result but got %+v", result)
+	}
+}
+
+func TestGiteaProviderSearchOtherAuthor(t *testing.T) {
+	provider, _, _, teardown := giteaSetup(t)
+	defer teardown()
+
+	_, err := provider.Search(context.Background(), "type:issue author:someone", &issues2markdown.SearchOptions{})
+	if err == nil {
+		t.Fatal("Expected an unsupported qualifier error")
+	}
+}
+
+func TestGiteaProviderSearchIssueFields(t *testing.T) {
+	provider, mux, _, teardown := giteaSetup(t)
+	mux.HandleFunc("/api/v1/repos/organization/repository/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"number": 1, "title": "Issue title 1", "state": "open", "html_url": "https://gitea.example.com/organization/repository/issues/1", "repository": {"owner": "organization", "name": "repository"},
+			"user": {"login": "username"},
+			"assignees": [{"login": "octocat"}],
+			"labels": [{"name": "bug", "color": "e11d21"}],
+			"milestone": {"title": "v1.0", "due_on": "2018-10-09T00:00:00Z"}}]`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "repo:organization/repository", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 {
+		t.Fatalf("Expected %d issues but got %d", 1, len(result.Issues))
+	}
+	testIssueFields(t, result.Issues[0], issues2markdown.Issue{
+		Author:    issues2markdown.User{Login: "username"},
+		Assignees: []issues2markdown.User{{Login: "octocat"}},
+		Labels:    []issues2markdown.Label{{Name: "bug", Color: "e11d21"}},
+		Milestone: &issues2markdown.Milestone{Title: "v1.0", DueOn: date(2018, 10, 9)},
+	})
+}
+
+func TestGiteaProviderSearchPullRequests(t *testing.T) {
+	provider, mux, _, teardown := giteaSetup(t)
+	mux.HandleFunc("/api/v1/repos/organization/repository/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.Query().Get("type"); got != "pulls" {
+			t.Errorf("Expected parameter %s=%q but got %q", "type", "pulls", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"number": 1, "title": "Pull request title 1", "state": "closed", "repository": {"owner": "organization", "name": "repository"}, "pull_request": {"merged": true, "draft": false}},
+			{"number": 2, "title": "Pull request title 2", "state": "open", "repository": {"owner": "organization", "name": "repository"}, "pull_request": {"merged": false, "draft": true}}]`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "type:pr repo:organization/repository", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 2 {
+		t.Fatalf("Expected %d pull requests but got %d", 2, len(result.Issues))
+	}
+	if issue := result.Issues[0]; !issue.IsPullRequest || !issue.Merged || issue.Draft {
+		t.Fatalf("Expected a merged pull request but got %+v", issue)
+	}
+	if issue := result.Issues[1]; !issue.IsPullRequest || issue.Merged || !issue.Draft {
+		t.Fatalf("Expected a draft pull request but got %+v", issue)
+	}
+}
+
+func TestGiteaProviderSearchAllTypes(t *testing.T) {
+	provider, mux, _, teardown := giteaSetup(t)
+	mux.HandleFunc("/api/v1/repos/organization/repository/issues", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["type"]; ok {
+			t.Errorf("Expected no type parameter but got %q", r.URL.Query().Get("type"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[]`)
+	})
+	defer teardown()
+
+	if _, err := provider.Search(context.Background(), "repo:organization/repository", &issues2markdown.SearchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGiteaProviderComments(t *testing.T) {
+	provider, mux, _, teardown := giteaSetup(t)
+	mux.HandleFunc("/api/v1/repos/organization/repository/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"body": "First comment", "user": {"login": "username"}, "html_url": "https://gitea.example.com/organization/repository/issues/1#issuecomment-1", "created_at": "2018-10-01T10:00:00Z"}]`)
+	})
+	defer teardown()
+
+	issue := &issues2markdown.Issue{Number: 1, Organization: "organization", Repository: "repository"}
+	comments, err := provider.Comments(context.Background(), issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].Body != "First comment" || comments[0].Author.Login != "username" {
+		t.Fatalf("Expected the first comment but got %+v", comments)
+	}
+}
@@ -0,0 +1,526 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	// DefaultGithubWebURL is the base URL of the Github web interface
+	DefaultGithubWebURL = "https://github.com/"
+
+	// githubSearchLimit is the maximum number of results of a Github search
+	githubSearchLimit = 1000
+)
+
+// GithubProvider is the IssueProvider for the Github REST API
+type GithubProvider struct {
+	client *github.Client
+	// WebURL is the base URL of the web interface the issues HTML URLs are
+	// relative to
+	WebURL *url.URL
+}
+
+// NewGithubProvider creates a GithubProvider instance that uses the provided
+// Github client
+func NewGithubProvider(client *github.Client) *GithubProvider {
+	webURL, _ := url.Parse(DefaultGithubWebURL)
+	provider := &GithubProvider{
+		client: client,
+		WebURL: webURL,
+	}
+	return provider
+}
+
+// NewGithubEnterpriseProvider creates a GithubProvider instance for a Github
+// Enterprise Server that uses the provided httpClient, which must handle the
+// authentication.
+//
+// The API base URL and upload URL default to the /api/v3/ and /api/uploads/
+// paths when only the server URL is provided. The web URL defaults to the
+// server URL.
+func NewGithubEnterpriseProvider(httpClient *http.Client, baseURL string, uploadURL string, webURL string) (*GithubProvider, error) {
+	parsedBaseURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(parsedBaseURL.Path, "/") {
+		parsedBaseURL.Path += "/"
+	}
+	if webURL == "" {
+		webURL = parsedBaseURL.String()
+		if idx := strings.Index(webURL, "/api/v3/"); idx >= 0 {
+			webURL = webURL[:idx+1]
+		}
+	}
+	if !strings.HasSuffix(parsedBaseURL.Path, "/api/v3/") {
+		parsedBaseURL.Path += "api/v3/"
+	}
+	if uploadURL == "" {
+		uploadURL = strings.TrimSuffix(parsedBaseURL.String(), "v3/") + "uploads/"
+	}
+
+	client, err := github.NewEnterpriseClient(parsedBaseURL.String(), uploadURL, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	provider := NewGithubProvider(client)
+	if !strings.HasSuffix(webURL, "/") {
+		webURL += "/"
+	}
+	provider.WebURL, err = url.Parse(webURL)
+	if err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// Authorize gets authentication information
+func (gp *GithubProvider) Authorize(ctx context.Context) (*User, error) {
+	// get user information
+	user, _, err := gp.client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	result := &User{
+		Login: user.GetLogin(),
+	}
+	return result, nil
+}
+
+// githubSearchResult represents the result of a Github search, which items
+// are decoded with decodeGithubIssue
+type githubSearchResult struct {
+	Total             int               `json:"total_count"`
+	IncompleteResults bool              `json:"incomplete_results"`
+	Items             []json.RawMessage `json:"items"`
+}
+
+// Search returns a page of the Issues that match the query
+//
+// The draft and merged state of the pull requests are in the search results,
+// their review state is fetched by Enrich with ReviewState.
+func (gp *GithubProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	if options.Page != "" {
+		page, err := strconv.Atoi(options.Page)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("page", strconv.Itoa(page))
+	}
+	req, err := gp.client.NewRequest("GET", "search/issues?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	listResult := &githubSearchResult{}
+	response, err := gp.client.Do(ctx, req, listResult)
+	if err != nil {
+		return nil, err
+	}
+
+	// process page results
+	result := &SearchResult{
+		Total:      listResult.Total,
+		Incomplete: listResult.IncompleteResults,
+	}
+	for _, v := range listResult.Items {
+		item, err := decodeGithubIssue(v)
+		if err != nil {
+			return nil, err
+		}
+		gp.resolveRepository(&item)
+		result.Issues = append(result.Issues, item)
+	}
+
+	// process pagination
+	if response.NextPage != 0 {
+		result.NextPage = strconv.Itoa(response.NextPage)
+	}
+	return result, nil
+}
+
+// SearchLimit returns the maximum number of results of a Github search
+func (gp *GithubProvider) SearchLimit() int {
+	return githubSearchLimit
+}
+
+// resolveRepository sets the organization and repository of the Issue from
+// its HTML URL, relative to the WebURL, so they are resolved for any base
+// path
+func (gp *GithubProvider) resolveRepository(issue *Issue) {
+	if gp.WebURL == nil || !strings.HasPrefix(issue.HTMLURL, gp.WebURL.String()) {
+		return
+	}
+	path := strings.TrimPrefix(issue.HTMLURL, gp.WebURL.String())
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return
+	}
+	issue.Organization = parts[0]
+	issue.Repository = parts[1]
+}
+
+// newIssueFromGithub creates an Issue from a Github issue
+func newIssueFromGithub(v *github.Issue) Issue {
+	item := Issue{
+		Number:  v.GetNumber(),
+		Title:   v.GetTitle(),
+		State:   v.GetState(),
+		URL:     v.GetURL(),
+		HTMLURL: v.GetHTMLURL(),
+		Author: User{
+			Login: v.GetUser().GetLogin(),
+		},
+		CreatedAt:     v.GetCreatedAt(),
+		UpdatedAt:     v.GetUpdatedAt(),
+		ClosedAt:      v.ClosedAt,
+		IsPullRequest: v.IsPullRequest(),
+		Body:          v.GetBody(),
+		CommentsCount: v.GetComments(),
+	}
+	// go-github doesn't decode the rocket and eyes reactions, they are only
+	// in the total count
+	if v.Reactions != nil {
+		item.Reactions = Reactions{
+			TotalCount: v.Reactions.GetTotalCount(),
+			PlusOne:    v.Reactions.GetPlusOne(),
+			MinusOne:   v.Reactions.GetMinusOne(),
+			Laugh:      v.Reactions.GetLaugh(),
+			Hooray:     v.Reactions.GetHooray(),
+			Confused:   v.Reactions.GetConfused(),
+			Heart:      v.Reactions.GetHeart(),
+		}
+	}
+	for _, assignee := range v.Assignees {
+		item.Assignees = append(item.Assignees, User{Login: assignee.GetLogin()})
+	}
+	for _, label := range v.Labels {
+		item.Labels = append(item.Labels, Label{Name: label.GetName(), Color: label.GetColor()})
+	}
+	if v.Milestone != nil {
+		item.Milestone = &Milestone{
+			Title: v.Milestone.GetTitle(),
+			DueOn: v.Milestone.DueOn,
+		}
+	}
+	return item
+}
+
+// decodeGithubIssue decodes a Github issue, with the fields that go-github
+// doesn't decode
+func decodeGithubIssue(data []byte) (Issue, error) {
+	v := github.Issue{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Issue{}, err
+	}
+	// the fields of the Github API that go-github doesn't decode
+	var extra struct {
+		Draft       bool `json:"draft"`
+		PullRequest *struct {
+			MergedAt *time.Time `json:"merged_at"`
+		} `json:"pull_request"`
+		Reactions struct {
+			Rocket int `json:"rocket"`
+			Eyes   int `json:"eyes"`
+		} `json:"reactions"`
+		Type *struct {
+			Name string `json:"name"`
+		} `json:"type"`
+	}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return Issue{}, err
+	}
+	issue := newIssueFromGithub(&v)
+	if issue.IsPullRequest {
+		issue.Draft = extra.Draft
+		issue.Merged = extra.PullRequest != nil && extra.PullRequest.MergedAt != nil
+	}
+	issue.Reactions.Rocket = extra.Reactions.Rocket
+	issue.Reactions.Eyes = extra.Reactions.Eyes
+	if extra.Type != nil {
+		issue.Type = extra.Type.Name
+	}
+	return issue, nil
+}
+
+// ReviewState returns the review state of a pull request, approved or
+// changes requested from the last review of each reviewer. The REST API
+// doesn't tell whether a review is required.
+func (gp *GithubProvider) ReviewState(ctx context.Context, issue *Issue) (string, error) {
+	organization, repository, err := issueRepository(issue)
+	if err != nil {
+		return "", err
+	}
+	states := make(map[string]string)
+	options := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, response, err := gp.client.PullRequests.ListReviews(ctx, organization, repository, issue.Number, options)
+		if err != nil {
+			return "", err
+		}
+		for _, review := range reviews {
+			switch review.GetState() {
+			case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+				states[review.GetUser().GetLogin()] = review.GetState()
+			}
+		}
+
+		// process pagination
+		if response.NextPage == 0 {
+			break
+		}
+		options.Page = response.NextPage
+	}
+	result := ""
+	for _, state := range states {
+		if state == "CHANGES_REQUESTED" {
+			return ReviewChangesRequested, nil
+		}
+		if state == "APPROVED" {
+			result = ReviewApproved
+		}
+	}
+	return result, nil
+}
+
+// Comments returns all the comments of the Issue, oldest first
+func (gp *GithubProvider) Comments(ctx context.Context, issue *Issue) ([]Comment, error) {
+	organization, repository, err := issueRepository(issue)
+	if err != nil {
+		return nil, err
+	}
+	var result []Comment
+	options := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		comments, response, err := gp.client.Issues.ListComments(ctx, organization, repository, issue.Number, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range comments {
+			result = append(result, Comment{
+				Author:    User{Login: v.GetUser().GetLogin()},
+				Body:      v.GetBody(),
+				HTMLURL:   v.GetHTMLURL(),
+				CreatedAt: v.GetCreatedAt(),
+			})
+		}
+
+		// process pagination
+		if response.NextPage == 0 {
+			break
+		}
+		options.Page = response.NextPage
+	}
+	return result, nil
+}
+
+// Teams returns the teams of the authorized user as ORGANIZATION/TEAM
+func (gp *GithubProvider) Teams(ctx context.Context) ([]string, error) {
+	var result []string
+	options := &github.ListOptions{PerPage: 100}
+	for {
+		teams, response, err := gp.client.Teams.ListUserTeams(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range teams {
+			result = append(result, v.GetOrganization().GetLogin()+"/"+v.GetSlug())
+		}
+
+		// process pagination
+		if response.NextPage == 0 {
+			break
+		}
+		options.Page = response.NextPage
+	}
+	return result, nil
+}
+
+// githubTimelineEvent represents a Github timeline event, which
+// cross-referenced source go-github doesn't decode
+type githubTimelineEvent struct {
+	Event     string       `json:"event"`
+	Actor     *github.User `json:"actor"`
+	CreatedAt time.Time    `json:"created_at"`
+	Label     *struct {
+		Name string `json:"name"`
+	} `json:"label"`
+	CommitID string `json:"commit_id"`
+	Source   *struct {
+		Issue struct {
+			Number      int    `json:"number"`
+			Title       string `json:"title"`
+			State       string `json:"state"`
+			HTMLURL     string `json:"html_url"`
+			Body        string `json:"body"`
+			Draft       bool   `json:"draft"`
+			PullRequest *struct {
+				MergedAt *time.Time `json:"merged_at"`
+			} `json:"pull_request"`
+			Repository struct {
+				Name  string `json:"name"`
+				Owner struct {
+					Login string `json:"login"`
+				} `json:"owner"`
+			} `json:"repository"`
+		} `json:"issue"`
+	} `json:"source"`
+}
+
+// timelineEvents returns all the Github timeline events of the Issue, oldest
+// first
+func (gp *GithubProvider) timelineEvents(ctx context.Context, issue *Issue) ([]githubTimelineEvent, error) {
+	organization, repository, err := issueRepository(issue)
+	if err != nil {
+		return nil, err
+	}
+	var result []githubTimelineEvent
+	page := 0
+	for {
+		u := fmt.Sprintf("repos/%s/%s/issues/%d/timeline?per_page=100", organization, repository, issue.Number)
+		if page != 0 {
+			u += fmt.Sprintf("&page=%d", page)
+		}
+		req, err := gp.client.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		var events []githubTimelineEvent
+		response, err := gp.client.Do(ctx, req, &events)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, events...)
+
+		// process pagination
+		if response.NextPage == 0 {
+			break
+		}
+		page = response.NextPage
+	}
+	return result, nil
+}
+
+// Timeline returns all the timeline events of the Issue, oldest first
+func (gp *GithubProvider) Timeline(ctx context.Context, issue *Issue) ([]Event, error) {
+	events, err := gp.timelineEvents(ctx, issue)
+	if err != nil {
+		return nil, err
+	}
+	var result []Event
+	for _, v := range events {
+		event := Event{
+			Type:      v.Event,
+			Actor:     User{Login: v.Actor.GetLogin()},
+			CreatedAt: v.CreatedAt,
+			CommitID:  v.CommitID,
+		}
+		if v.Label != nil {
+			event.Label = v.Label.Name
+		}
+		if v.Source != nil {
+			event.SourceURL = v.Source.Issue.HTMLURL
+		}
+		result = append(result, event)
+	}
+	return result, nil
+}
+
+// SubIssues returns the sub-issues of the Issue
+func (gp *GithubProvider) SubIssues(ctx context.Context, issue *Issue, options *SearchOptions) ([]Issue, error) {
+	organization, repository, err := issueRepository(issue)
+	if err != nil {
+		return nil, err
+	}
+	var result []Issue
+	page := 0
+	for {
+		u := fmt.Sprintf("repos/%s/%s/issues/%d/sub_issues?per_page=100", organization, repository, issue.Number)
+		if page != 0 {
+			u += fmt.Sprintf("&page=%d", page)
+		}
+		req, err := gp.client.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		var subIssues []json.RawMessage
+		response, err := gp.client.Do(ctx, req, &subIssues)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range subIssues {
+			item, err := decodeGithubIssue(v)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, item)
+		}
+
+		// process pagination
+		if response.NextPage == 0 {
+			break
+		}
+		page = response.NextPage
+	}
+	return result, nil
+}
+
+// LinkedPullRequests returns the pull requests that cross-referenced the
+// Issue, oldest first. The REST API doesn't tell the closing references, so
+// the pull requests close the Issue when their body has a closing keyword
+// for it.
+func (gp *GithubProvider) LinkedPullRequests(ctx context.Context, issue *Issue) ([]LinkedPullRequest, error) {
+	events, err := gp.timelineEvents(ctx, issue)
+	if err != nil {
+		return nil, err
+	}
+	var result []LinkedPullRequest
+	for _, v := range events {
+		if v.Event != "cross-referenced" || v.Source == nil || v.Source.Issue.PullRequest == nil {
+			continue
+		}
+		source := v.Source.Issue
+		pr := LinkedPullRequest{
+			Number:       source.Number,
+			Title:        source.Title,
+			State:        source.State,
+			HTMLURL:      source.HTMLURL,
+			Organization: source.Repository.Owner.Login,
+			Repository:   source.Repository.Name,
+			Draft:        source.Draft,
+			Merged:       source.PullRequest.MergedAt != nil,
+		}
+		pr.Closes = closesIssue(source.Body, pr.Organization, pr.Repository, issue)
+		result = append(result, pr)
+	}
+	return result, nil
+}
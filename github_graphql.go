@@ -0,0 +1,749 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultGithubGraphQLURL is the endpoint of the Github GraphQL API
+	DefaultGithubGraphQLURL = "https://api.github.com/graphql"
+
+	// githubGraphQLPageSize is the number of issues requested per page
+	githubGraphQLPageSize = 100
+)
+
+// githubGraphQLViewerQuery is the GraphQL query of the authenticated user
+const githubGraphQLViewerQuery = `query {
+  viewer {
+    login
+  }
+}`
+
+// githubGraphQLSearchQuery is the GraphQL query of a page of issues search
+// results with all the fields needed to create the Issues
+const githubGraphQLSearchQuery = `query($query: String!, $first: Int!, $after: String, $includeBody: Boolean!) {
+  search(query: $query, type: ISSUE, first: $first, after: $after) {
+    issueCount
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+    nodes {
+      __typename
+      ... on Issue {
+        ...issueFields
+      }
+      ... on PullRequest {
+        ...pullRequestFields
+      }
+    }
+  }
+}
+
+` + githubGraphQLFragments
+
+// githubGraphQLHierarchySearchQuery is the githubGraphQLSearchQuery with the
+// hierarchy fields of the issues, which the servers without sub-issues or
+// issue types reject
+const githubGraphQLHierarchySearchQuery = `query($query: String!, $first: Int!, $after: String, $includeBody: Boolean!) {
+  search(query: $query, type: ISSUE, first: $first, after: $after) {
+    issueCount
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+    nodes {
+      __typename
+      ... on Issue {
+        ...issueFields
+        ...issueHierarchyFields
+      }
+      ... on PullRequest {
+        ...pullRequestFields
+      }
+    }
+  }
+}
+
+` + githubGraphQLFragments + githubGraphQLHierarchyFragments
+
+// githubGraphQLSubIssuesQuery is the GraphQL query of a page of the
+// sub-issues of an issue
+const githubGraphQLSubIssuesQuery = `query($owner: String!, $name: String!, $number: Int!, $after: String, $includeBody: Boolean!) {
+  repository(owner: $owner, name: $name) {
+    issue(number: $number) {
+      subIssues(first: 100, after: $after) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          __typename
+          ...issueFields
+          ...issueHierarchyFields
+        }
+      }
+    }
+  }
+}
+
+` + githubGraphQLFragments + githubGraphQLHierarchyFragments
+
+// githubGraphQLFragments are the fragments with all the fields needed to
+// create the Issues
+const githubGraphQLFragments = `fragment issueFields on Issue {
+  number
+  title
+  state
+  url
+  repository {
+    name
+    owner {
+      login
+    }
+  }
+  author {
+    login
+  }
+  assignees(first: 10) {
+    nodes {
+      login
+    }
+  }
+  labels(first: 20) {
+    nodes {
+      name
+      color
+    }
+  }
+  milestone {
+    title
+    dueOn
+  }
+  createdAt
+  updatedAt
+  closedAt
+  body @include(if: $includeBody)
+  comments {
+    totalCount
+  }
+  reactionGroups {
+    content
+    reactors {
+      totalCount
+    }
+  }
+}
+
+fragment pullRequestFields on PullRequest {
+  isDraft
+  merged
+  reviewDecision
+  number
+  title
+  state
+  url
+  repository {
+    name
+    owner {
+      login
+    }
+  }
+  author {
+    login
+  }
+  assignees(first: 10) {
+    nodes {
+      login
+    }
+  }
+  labels(first: 20) {
+    nodes {
+      name
+      color
+    }
+  }
+  milestone {
+    title
+    dueOn
+  }
+  createdAt
+  updatedAt
+  closedAt
+  body @include(if: $includeBody)
+  comments {
+    totalCount
+  }
+  reactionGroups {
+    content
+    reactors {
+      totalCount
+    }
+  }
+}`
+
+// githubGraphQLHierarchyFragments are the fragments with the issue type,
+// parent and sub-issues of the issues
+const githubGraphQLHierarchyFragments = `
+
+fragment issueHierarchyFields on Issue {
+  issueType {
+    name
+  }
+  parent {
+    ...issueReferenceFields
+  }
+  subIssues(first: 50) {
+    nodes {
+      ...issueReferenceFields
+    }
+  }
+}
+
+fragment issueReferenceFields on Issue {
+  number
+  title
+  state
+  url
+  repository {
+    name
+    owner {
+      login
+    }
+  }
+}`
+
+// githubGraphQLLinkedPullRequestsQuery is the GraphQL query of a page of the
+// cross-references of an issue
+const githubGraphQLLinkedPullRequestsQuery = `query($owner: String!, $name: String!, $number: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    issue(number: $number) {
+      timelineItems(first: 100, after: $after, itemTypes: [CROSS_REFERENCED_EVENT]) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          ... on CrossReferencedEvent {
+            willCloseTarget
+            source {
+              __typename
+              ... on PullRequest {
+                number
+                title
+                state
+                url
+                isDraft
+                merged
+                repository {
+                  name
+                  owner {
+                    login
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// GithubGraphQLProvider is the IssueProvider for the Github GraphQL API. It
+// retrieves all the Issue fields in a single query per page.
+type GithubGraphQLProvider struct {
+	client *http.Client
+	URL    *url.URL
+}
+
+// NewGithubGraphQLProvider creates a GithubGraphQLProvider instance that
+// uses the provided httpClient, which must handle the authentication, for
+// example an oauth2 client.
+func NewGithubGraphQLProvider(httpClient *http.Client) *GithubGraphQLProvider {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	endpoint, _ := url.Parse(DefaultGithubGraphQLURL)
+	provider := &GithubGraphQLProvider{
+		client: httpClient,
+		URL:    endpoint,
+	}
+	return provider
+}
+
+// NewGithubEnterpriseGraphQLProvider creates a GithubGraphQLProvider
+// instance for the Github Enterprise Server at baseURL, which GraphQL
+// endpoint is /api/graphql.
+func NewGithubEnterpriseGraphQLProvider(httpClient *http.Client, baseURL string) (*GithubGraphQLProvider, error) {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	parsedBaseURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := parsedBaseURL.Parse("api/graphql")
+	if err != nil {
+		return nil, err
+	}
+	provider := NewGithubGraphQLProvider(httpClient)
+	provider.URL = endpoint
+	return provider, nil
+}
+
+// githubGraphQLRequest represents a GraphQL request
+type githubGraphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// githubGraphQLResponse represents a GraphQL response
+type githubGraphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// githubGraphQLIssue represents the fields of an issue or a pull request
+type githubGraphQLIssue struct {
+	TypeName   string `json:"__typename"`
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	State      string `json:"state"`
+	URL        string `json:"url"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Assignees struct {
+		Nodes []struct {
+			Login string `json:"login"`
+		} `json:"nodes"`
+	} `json:"assignees"`
+	Labels struct {
+		Nodes []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title string     `json:"title"`
+		DueOn *time.Time `json:"dueOn"`
+	} `json:"milestone"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	ClosedAt  *time.Time `json:"closedAt"`
+	Body      string     `json:"body"`
+	Comments  struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"comments"`
+	ReactionGroups []struct {
+		Content  string `json:"content"`
+		Reactors struct {
+			TotalCount int `json:"totalCount"`
+		} `json:"reactors"`
+	} `json:"reactionGroups"`
+	// issue fields
+	IssueType *struct {
+		Name string `json:"name"`
+	} `json:"issueType"`
+	Parent    *githubGraphQLIssueReference `json:"parent"`
+	SubIssues struct {
+		Nodes []githubGraphQLIssueReference `json:"nodes"`
+	} `json:"subIssues"`
+	// pull request fields
+	IsDraft        bool   `json:"isDraft"`
+	Merged         bool   `json:"merged"`
+	ReviewDecision string `json:"reviewDecision"`
+}
+
+// githubGraphQLIssueReference represents the reference of a Github GraphQL
+// issue
+type githubGraphQLIssueReference struct {
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	State      string `json:"state"`
+	URL        string `json:"url"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+// newIssueReference creates an IssueReference from a Github GraphQL issue
+// reference
+func (v *githubGraphQLIssueReference) newIssueReference() IssueReference {
+	reference := IssueReference{
+		Number:       v.Number,
+		Title:        v.Title,
+		State:        strings.ToLower(v.State),
+		HTMLURL:      v.URL,
+		Organization: v.Repository.Owner.Login,
+		Repository:   v.Repository.Name,
+	}
+	return reference
+}
+
+// githubGraphQLSearch represents the data of a search query
+type githubGraphQLSearch struct {
+	Search struct {
+		IssueCount int `json:"issueCount"`
+		PageInfo   struct {
+			HasNextPage bool   `json:"hasNextPage"`
+			EndCursor   string `json:"endCursor"`
+		} `json:"pageInfo"`
+		Nodes []githubGraphQLIssue `json:"nodes"`
+	} `json:"search"`
+}
+
+// Authorize gets authentication information
+func (gp *GithubGraphQLProvider) Authorize(ctx context.Context) (*User, error) {
+	var data struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+	if err := gp.query(ctx, githubGraphQLViewerQuery, nil, &data); err != nil {
+		return nil, err
+	}
+	result := &User{
+		Login: data.Viewer.Login,
+	}
+	return result, nil
+}
+
+// Search returns a page of the Issues that match the query. The page is the
+// cursor of the search connection.
+func (gp *GithubGraphQLProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	variables := map[string]interface{}{
+		"query":       query,
+		"first":       githubGraphQLPageSize,
+		"includeBody": options.IncludeBody,
+	}
+	if options.Page != "" {
+		variables["after"] = options.Page
+	}
+	searchQuery := githubGraphQLSearchQuery
+	if options.Hierarchy {
+		searchQuery = githubGraphQLHierarchySearchQuery
+	}
+	data := &githubGraphQLSearch{}
+	if err := gp.query(ctx, searchQuery, variables, data); err != nil {
+		return nil, err
+	}
+
+	// process page results
+	result := &SearchResult{
+		Total: data.Search.IssueCount,
+	}
+	for _, v := range data.Search.Nodes {
+		// nodes of other types are empty
+		if v.Number == 0 {
+			continue
+		}
+		result.Issues = append(result.Issues, gp.newIssueFromGithubGraphQL(&v))
+	}
+
+	// process pagination
+	if data.Search.PageInfo.HasNextPage {
+		result.NextPage = data.Search.PageInfo.EndCursor
+	}
+	return result, nil
+}
+
+// SearchLimit returns the maximum number of results of a Github search
+func (gp *GithubGraphQLProvider) SearchLimit() int {
+	return githubSearchLimit
+}
+
+// query sends a GraphQL query and decodes the response data into v
+func (gp *GithubGraphQLProvider) query(ctx context.Context, query string, variables map[string]interface{}, v interface{}) error {
+	body, err := json.Marshal(&githubGraphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", gp.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// the sub-issues and issue types are preview features
+	req.Header.Set("GraphQL-Features", "sub_issues,issue_types")
+
+	response := &githubGraphQLResponse{}
+	if _, err := doJSON(ctx, gp.client, req, response); err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		messages := make([]string, len(response.Errors))
+		for i, e := range response.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("github graphql: %s", strings.Join(messages, "; "))
+	}
+	return json.Unmarshal(response.Data, v)
+}
+
+// LinkedPullRequests returns the pull requests that cross-referenced the
+// Issue, oldest first. Pull requests have no linked pull requests.
+func (gp *GithubGraphQLProvider) LinkedPullRequests(ctx context.Context, issue *Issue) ([]LinkedPullRequest, error) {
+	if issue.IsPullRequest {
+		return nil, nil
+	}
+	organization, repository, err := issueRepository(issue)
+	if err != nil {
+		return nil, err
+	}
+	variables := map[string]interface{}{
+		"owner":  organization,
+		"name":   repository,
+		"number": issue.Number,
+	}
+	var result []LinkedPullRequest
+	for {
+		var data struct {
+			Repository struct {
+				Issue struct {
+					TimelineItems struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							WillCloseTarget bool `json:"willCloseTarget"`
+							Source          struct {
+								TypeName   string `json:"__typename"`
+								Number     int    `json:"number"`
+								Title      string `json:"title"`
+								State      string `json:"state"`
+								URL        string `json:"url"`
+								IsDraft    bool   `json:"isDraft"`
+								Merged     bool   `json:"merged"`
+								Repository struct {
+									Name  string `json:"name"`
+									Owner struct {
+										Login string `json:"login"`
+									} `json:"owner"`
+								} `json:"repository"`
+							} `json:"source"`
+						} `json:"nodes"`
+					} `json:"timelineItems"`
+				} `json:"issue"`
+			} `json:"repository"`
+		}
+		if err := gp.query(ctx, githubGraphQLLinkedPullRequestsQuery, variables, &data); err != nil {
+			return nil, err
+		}
+		timeline := data.Repository.Issue.TimelineItems
+		for _, v := range timeline.Nodes {
+			if v.Source.TypeName != "PullRequest" {
+				continue
+			}
+			pr := LinkedPullRequest{
+				Number:       v.Source.Number,
+				Title:        v.Source.Title,
+				State:        strings.ToLower(v.Source.State),
+				HTMLURL:      v.Source.URL,
+				Organization: v.Source.Repository.Owner.Login,
+				Repository:   v.Source.Repository.Name,
+				Draft:        v.Source.IsDraft,
+				Merged:       v.Source.Merged,
+				Closes:       v.WillCloseTarget,
+			}
+			// merged pull requests are closed
+			if pr.State == "merged" {
+				pr.State = "closed"
+			}
+			result = append(result, pr)
+		}
+
+		// process pagination
+		if !timeline.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = timeline.PageInfo.EndCursor
+	}
+	return result, nil
+}
+
+// SubIssues returns the sub-issues of the Issue. Pull requests have no
+// sub-issues.
+func (gp *GithubGraphQLProvider) SubIssues(ctx context.Context, issue *Issue, options *SearchOptions) ([]Issue, error) {
+	if issue.IsPullRequest {
+		return nil, nil
+	}
+	organization, repository, err := issueRepository(issue)
+	if err != nil {
+		return nil, err
+	}
+	variables := map[string]interface{}{
+		"owner":       organization,
+		"name":        repository,
+		"number":      issue.Number,
+		"includeBody": options.IncludeBody,
+	}
+	var result []Issue
+	for {
+		var data struct {
+			Repository struct {
+				Issue struct {
+					SubIssues struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []githubGraphQLIssue `json:"nodes"`
+					} `json:"subIssues"`
+				} `json:"issue"`
+			} `json:"repository"`
+		}
+		if err := gp.query(ctx, githubGraphQLSubIssuesQuery, variables, &data); err != nil {
+			return nil, err
+		}
+		subIssues := data.Repository.Issue.SubIssues
+		for _, v := range subIssues.Nodes {
+			result = append(result, gp.newIssueFromGithubGraphQL(&v))
+		}
+
+		// process pagination
+		if !subIssues.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = subIssues.PageInfo.EndCursor
+	}
+	return result, nil
+}
+
+// restURL returns the REST API URL of the path, relative to the REST API
+// base URL that matches the GraphQL endpoint
+func (gp *GithubGraphQLProvider) restURL(path string) string {
+	base := ""
+	switch {
+	case gp.URL.Path == "/graphql":
+		// https://api.github.com/graphql
+		base = "/"
+	case strings.HasSuffix(gp.URL.Path, "/api/graphql"):
+		// Github Enterprise Server https://hostname/api/graphql
+		base = strings.TrimSuffix(gp.URL.Path, "graphql") + "v3/"
+	default:
+		return ""
+	}
+	u, err := gp.URL.Parse(base + path)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+// newIssueFromGithubGraphQL creates an Issue from a Github GraphQL issue or
+// pull request
+func (gp *GithubGraphQLProvider) newIssueFromGithubGraphQL(v *githubGraphQLIssue) Issue {
+	item := Issue{
+		Number:        v.Number,
+		Title:         v.Title,
+		State:         strings.ToLower(v.State),
+		HTMLURL:       v.URL,
+		Organization:  v.Repository.Owner.Login,
+		Repository:    v.Repository.Name,
+		CreatedAt:     v.CreatedAt,
+		UpdatedAt:     v.UpdatedAt,
+		ClosedAt:      v.ClosedAt,
+		IsPullRequest: v.TypeName == "PullRequest",
+		Draft:         v.IsDraft,
+		Merged:        v.Merged,
+		Body:          v.Body,
+		CommentsCount: v.Comments.TotalCount,
+	}
+	for _, group := range v.ReactionGroups {
+		count := group.Reactors.TotalCount
+		item.Reactions.TotalCount += count
+		switch group.Content {
+		case "THUMBS_UP":
+			item.Reactions.PlusOne = count
+		case "THUMBS_DOWN":
+			item.Reactions.MinusOne = count
+		case "LAUGH":
+			item.Reactions.Laugh = count
+		case "HOORAY":
+			item.Reactions.Hooray = count
+		case "CONFUSED":
+			item.Reactions.Confused = count
+		case "HEART":
+			item.Reactions.Heart = count
+		case "ROCKET":
+			item.Reactions.Rocket = count
+		case "EYES":
+			item.Reactions.Eyes = count
+		}
+	}
+	// the review decisions are the upper case review states
+	item.ReviewState = strings.ToLower(v.ReviewDecision)
+	// merged pull requests are closed
+	if item.State == "merged" {
+		item.State = "closed"
+	}
+	// the author of deleted accounts is null
+	if v.Author != nil {
+		item.Author.Login = v.Author.Login
+	}
+	for _, assignee := range v.Assignees.Nodes {
+		item.Assignees = append(item.Assignees, User{Login: assignee.Login})
+	}
+	for _, label := range v.Labels.Nodes {
+		item.Labels = append(item.Labels, Label{Name: label.Name, Color: label.Color})
+	}
+	if v.Milestone != nil {
+		item.Milestone = &Milestone{
+			Title: v.Milestone.Title,
+			DueOn: v.Milestone.DueOn,
+		}
+	}
+	if v.IssueType != nil {
+		item.Type = v.IssueType.Name
+	}
+	if v.Parent != nil {
+		parent := v.Parent.newIssueReference()
+		item.Parent = &parent
+	}
+	for _, subIssue := range v.SubIssues.Nodes {
+		item.SubIssues = append(item.SubIssues, subIssue.newIssueReference())
+	}
+	item.URL = gp.restURL(fmt.Sprintf("repos/%s/%s/issues/%d", item.Organization, item.Repository, item.Number))
+	return item
+}
@@ -0,0 +1,360 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// githubGraphQLSetup sets up a test HTTP server along with a
+// GithubGraphQLProvider that is configured to talk to that test server.
+//
+// The handler receives the decoded GraphQL query and variables.
+func githubGraphQLSetup(t *testing.T, path string, handler func(w http.ResponseWriter, query string, variables map[string]interface{})) (provider *issues2markdown.GithubGraphQLProvider, teardown func()) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var request struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, request.Query, request.Variables)
+	})
+	server := httptest.NewServer(mux)
+
+	provider = issues2markdown.NewGithubGraphQLProvider(nil)
+	provider.URL, _ = url.Parse(server.URL + path)
+	return provider, server.Close
+}
+
+func TestGithubGraphQLProviderAuthorize(t *testing.T) {
+	provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+		if !strings.Contains(query, "viewer") {
+			t.Errorf("Expected a viewer query but got %q", query)
+		}
+		fmt.Fprint(w, `{"data": {"viewer": {"login": "username"}}}`)
+	})
+	defer teardown()
+
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "username" {
+		t.Fatalf("Expected login %q but got %q", "username", user.Login)
+	}
+}
+
+func TestGithubGraphQLProviderErrors(t *testing.T) {
+	provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+		fmt.Fprint(w, `{"data": null, "errors": [{"message": "Bad credentials"}]}`)
+	})
+	defer teardown()
+
+	_, err := provider.Authorize(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Fatalf("Expected a GraphQL error but got %v", err)
+	}
+}
+
+func TestGithubGraphQLProviderQuery(t *testing.T) {
+	provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+		if strings.Contains(query, "viewer") {
+			fmt.Fprint(w, `{"data": {"viewer": {"login": "username"}}}`)
+			return
+		}
+		if variables["query"] != "type:issue is:open author:username archived:false" {
+			t.Errorf("Expected search query %q but got %q", "type:issue is:open author:username archived:false", variables["query"])
+		}
+		issue := `{"number": %d, "title": "Issue title %d", "state": "%s", "url": "https://github.com/username/repo/issues/%d", "repository": {"name": "repo", "owner": {"login": "username"}}}`
+		switch variables["after"] {
+		case nil:
+			fmt.Fprintf(w, `{"data": {"search": {"issueCount": 2, "pageInfo": {"hasNextPage": true, "endCursor": "Y3Vyc29yOjE="}, "nodes": [`+issue+`]}}}`, 1, 1, "OPEN", 1)
+		case "Y3Vyc29yOjE=":
+			fmt.Fprintf(w, `{"data": {"search": {"issueCount": 2, "pageInfo": {"hasNextPage": false, "endCursor": "Y3Vyc29yOjI="}, "nodes": [`+issue+`, {}]}}}`, 2, 2, "CLOSED", 2)
+		default:
+			t.Errorf("Unexpected cursor %v", variables["after"])
+		}
+	})
+	defer teardown()
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	options.Organization = i2md.User.Login
+	issues, err := i2md.Query(options, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("Expected %d issues but got %d", 2, len(issues))
+	}
+	if !strings.HasSuffix(issues[0].URL, "/repos/username/repo/issues/1") {
+		t.Fatalf("Expected the REST API URL of the issue but got %q", issues[0].URL)
+	}
+
+	markdown, err := i2md.Render(issues, issues2markdown.NewRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMarkdown := `- [ ] username/repo : [#1 Issue title 1](https://github.com/username/repo/issues/1)
+- [x] username/repo : [#2 Issue title 2](https://github.com/username/repo/issues/2)`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestGithubEnterpriseGraphQLProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data": {"search": {"issueCount": 1, "pageInfo": {"hasNextPage": false}, "nodes": [{"number": 1, "title": "Issue title 1", "state": "OPEN", "url": "https://%s/organization/repository/issues/1", "repository": {"name": "repository", "owner": {"login": "organization"}}}]}}}`, r.Host)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider, err := issues2markdown.NewGithubEnterpriseGraphQLProvider(nil, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 {
+		t.Fatalf("Expected %d issues but got %d", 1, len(result.Issues))
+	}
+	expectedURL := server.URL + "/api/v3/repos/organization/repository/issues/1"
+	if result.Issues[0].URL != expectedURL {
+		t.Fatalf("Expected URL %q but got %q", expectedURL, result.Issues[0].URL)
+	}
+}
+
+func TestGithubGraphQLProviderSearchIssueFields(t *testing.T) {
+	provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+		fmt.Fprint(w, `{"data": {"search": {"issueCount": 2, "pageInfo": {"hasNextPage": false}, "nodes": [
+			{"number": 1, "title": "Issue title 1", "state": "OPEN", "url": "https://github.com/username/repo/issues/1", "repository": {"name": "repo", "owner": {"login": "username"}},
+				"author": {"login": "username"},
+				"assignees": {"nodes": [{"login": "octocat"}]},
+				"labels": {"nodes": [{"name": "bug", "color": "d73a4a"}]},
+				"milestone": {"title": "v1.0", "dueOn": "2018-10-09T00:00:00Z"}},
+			{"number": 2, "title": "Issue title 2", "state": "OPEN", "url": "https://github.com/username/repo/issues/2", "repository": {"name": "repo", "owner": {"login": "username"}},
+				"author": null, "assignees": {"nodes": []}, "labels": {"nodes": []}, "milestone": null}]}}}`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 2 {
+		t.Fatalf("Expected %d issues but got %d", 2, len(result.Issues))
+	}
+	testIssueFields(t, result.Issues[0], issues2markdown.Issue{
+		Author:    issues2markdown.User{Login: "username"},
+		Assignees: []issues2markdown.User{{Login: "octocat"}},
+		Labels:    []issues2markdown.Label{{Name: "bug", Color: "d73a4a"}},
+		Milestone: &issues2markdown.Milestone{Title: "v1.0", DueOn: date(2018, 10, 9)},
+	})
+	testIssueFields(t, result.Issues[1], issues2markdown.Issue{})
+	if result.Total != 2 {
+		t.Fatalf("Expected %d results but got %d", 2, result.Total)
+	}
+}
+
+func TestGithubGraphQLProviderSearchReactions(t *testing.T) {
+	provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+		fmt.Fprint(w, `{"data": {"search": {"issueCount": 1, "pageInfo": {"hasNextPage": false}, "nodes": [
+			{"number": 1, "title": "Issue title 1", "state": "OPEN", "url": "https://github.com/username/repo/issues/1", "repository": {"name": "repo", "owner": {"login": "username"}},
+				"comments": {"totalCount": 7},
+				"reactionGroups": [{"content": "THUMBS_UP", "reactors": {"totalCount": 3}}, {"content": "HEART", "reactors": {"totalCount": 1}},
+					{"content": "ROCKET", "reactors": {"totalCount": 2}}, {"content": "EYES", "reactors": {"totalCount": 0}}]}]}}}`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := result.Issues[0]
+	if issue.CommentsCount != 7 {
+		t.Fatalf("Expected %d comments but got %d", 7, issue.CommentsCount)
+	}
+	expectedReactions := issues2markdown.Reactions{TotalCount: 6, PlusOne: 3, Heart: 1, Rocket: 2}
+	if issue.Reactions != expectedReactions {
+		t.Fatalf("Expected reactions %+v but got %+v", expectedReactions, issue.Reactions)
+	}
+}
+
+func TestGithubGraphQLProviderSearchPullRequests(t *testing.T) {
+	provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+		fmt.Fprint(w, `{"data": {"search": {"issueCount": 3, "pageInfo": {"hasNextPage": false}, "nodes": [
+			{"__typename": "PullRequest", "number": 1, "title": "Pull request title 1", "state": "OPEN", "url": "https://github.com/username/repo/pull/1", "repository": {"name": "repo", "owner": {"login": "username"}},
+				"isDraft": true, "merged": false, "reviewDecision": "REVIEW_REQUIRED"},
+			{"__typename": "PullRequest", "number": 2, "title": "Pull request title 2", "state": "MERGED", "url": "https://github.com/username/repo/pull/2", "repository": {"name": "repo", "owner": {"login": "username"}},
+				"isDraft": false, "merged": true, "reviewDecision": "APPROVED"},
+			{"__typename": "Issue", "number": 3, "title": "Issue title 3", "state": "OPEN", "url": "https://github.com/username/repo/issues/3", "repository": {"name": "repo", "owner": {"login": "username"}}}]}}}`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "repo:username/repo", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 3 {
+		t.Fatalf("Expected %d issues but got %d", 3, len(result.Issues))
+	}
+	draft := result.Issues[0]
+	if !draft.IsPullRequest || !draft.Draft || draft.Merged || draft.ReviewState != issues2markdown.ReviewRequired {
+		t.Fatalf("Expected a draft pull request that requires a review but got %+v", draft)
+	}
+	merged := result.Issues[1]
+	if !merged.IsPullRequest || !merged.Merged || merged.State != "closed" || merged.ReviewState != issues2markdown.ReviewApproved {
+		t.Fatalf("Expected an approved merged pull request but got %+v", merged)
+	}
+	if issue := result.Issues[2]; issue.IsPullRequest || issue.ReviewState != "" {
+		t.Fatalf("Expected an issue but got %+v", issue)
+	}
+}
+
+func TestGithubGraphQLProviderSearchIncludeBody(t *testing.T) {
+	for _, includeBody := range []bool{false, true} {
+		provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+			if !strings.Contains(query, "body @include(if: $includeBody)") {
+				t.Errorf("Expected a conditional body in query %q", query)
+			}
+			for _, field := range []string{"issueType", "parent", "subIssues"} {
+				if strings.Contains(query, field) {
+					t.Errorf("Expected no %s field without hierarchy in query %q", field, query)
+				}
+			}
+			if variables["includeBody"] != includeBody {
+				t.Errorf("Expected includeBody %v but got %v", includeBody, variables["includeBody"])
+			}
+			fmt.Fprint(w, `{"data": {"search": {"issueCount": 1, "pageInfo": {"hasNextPage": false}, "nodes": [
+				{"number": 1, "title": "Issue title 1", "state": "OPEN", "url": "https://github.com/username/repo/issues/1", "repository": {"name": "repo", "owner": {"login": "username"}}, "body": "- [x] done"}]}}}`)
+		})
+
+		result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{IncludeBody: includeBody})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Issues[0].Body != "- [x] done" {
+			t.Fatalf("Expected body %q but got %q", "- [x] done", result.Issues[0].Body)
+		}
+		teardown()
+	}
+}
+
+func TestGithubGraphQLProviderLinkedPullRequests(t *testing.T) {
+	provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+		if variables["owner"] != "username" || variables["name"] != "repo" || variables["number"] != float64(1) {
+			t.Errorf("Expected the variables of username/repo#1 but got %v", variables)
+		}
+		if variables["after"] == nil {
+			fmt.Fprint(w, `{"data": {"repository": {"issue": {"timelineItems": {"pageInfo": {"hasNextPage": true, "endCursor": "cursor"}, "nodes": [
+				{"willCloseTarget": false, "source": {"__typename": "Issue", "number": 7}},
+				{"willCloseTarget": true, "source": {"__typename": "PullRequest", "number": 8, "title": "Fix the bug", "state": "MERGED", "url": "https://github.com/username/repo/pull/8", "isDraft": false, "merged": true, "repository": {"name": "repo", "owner": {"login": "username"}}}}]}}}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"repository": {"issue": {"timelineItems": {"pageInfo": {"hasNextPage": false}, "nodes": [
+			{"willCloseTarget": false, "source": {"__typename": "PullRequest", "number": 3, "title": "Refactor", "state": "OPEN", "url": "https://github.com/username/lib/pull/3", "isDraft": true, "merged": false, "repository": {"name": "lib", "owner": {"login": "username"}}}}]}}}}}`)
+	})
+	defer teardown()
+
+	issue := &issues2markdown.Issue{Number: 1, Organization: "username", Repository: "repo"}
+	pullRequests, err := provider.LinkedPullRequests(context.Background(), issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []issues2markdown.LinkedPullRequest{
+		{Number: 8, Title: "Fix the bug", State: "closed", HTMLURL: "https://github.com/username/repo/pull/8", Organization: "username", Repository: "repo", Merged: true, Closes: true},
+		{Number: 3, Title: "Refactor", State: "open", HTMLURL: "https://github.com/username/lib/pull/3", Organization: "username", Repository: "lib", Draft: true},
+	}
+	if !reflect.DeepEqual(pullRequests, expected) {
+		t.Fatalf("Expected pull requests %+v but got %+v", expected, pullRequests)
+	}
+}
+
+func TestGithubGraphQLProviderSearchHierarchy(t *testing.T) {
+	provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+		if !strings.Contains(query, "...issueHierarchyFields") {
+			t.Errorf("Expected the hierarchy fields in query %q", query)
+		}
+		fmt.Fprint(w, `{"data": {"search": {"issueCount": 1, "pageInfo": {"hasNextPage": false}, "nodes": [
+			{"__typename": "Issue", "number": 2, "title": "Issue title 2", "state": "OPEN", "url": "https://github.com/username/repo/issues/2", "repository": {"name": "repo", "owner": {"login": "username"}},
+				"issueType": {"name": "Feature"},
+				"parent": {"number": 1, "title": "Epic", "state": "OPEN", "url": "https://github.com/username/planning/issues/1", "repository": {"name": "planning", "owner": {"login": "username"}}},
+				"subIssues": {"nodes": [{"number": 3, "title": "Task", "state": "CLOSED", "url": "https://github.com/username/repo/issues/3", "repository": {"name": "repo", "owner": {"login": "username"}}}]}}]}}}`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{Hierarchy: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := result.Issues[0]
+	if issue.Type != "Feature" {
+		t.Fatalf("Expected type %q but got %q", "Feature", issue.Type)
+	}
+	expectedParent := &issues2markdown.IssueReference{Number: 1, Title: "Epic", State: "open", HTMLURL: "https://github.com/username/planning/issues/1", Organization: "username", Repository: "planning"}
+	if !reflect.DeepEqual(issue.Parent, expectedParent) {
+		t.Fatalf("Expected parent %+v but got %+v", expectedParent, issue.Parent)
+	}
+	expectedSubIssues := []issues2markdown.IssueReference{{Number: 3, Title: "Task", State: "closed", HTMLURL: "https://github.com/username/repo/issues/3", Organization: "username", Repository: "repo"}}
+	if !reflect.DeepEqual(issue.SubIssues, expectedSubIssues) {
+		t.Fatalf("Expected sub-issues %+v but got %+v", expectedSubIssues, issue.SubIssues)
+	}
+}
+
+func TestGithubGraphQLProviderSubIssues(t *testing.T) {
+	provider, teardown := githubGraphQLSetup(t, "/graphql", func(w http.ResponseWriter, query string, variables map[string]interface{}) {
+		if variables["number"] != float64(1) || variables["includeBody"] != true {
+			t.Errorf("Expected the variables of #1 with bodies but got %v", variables)
+		}
+		fmt.Fprint(w, `{"data": {"repository": {"issue": {"subIssues": {"pageInfo": {"hasNextPage": false}, "nodes": [
+			{"__typename": "Issue", "number": 3, "title": "Task", "state": "OPEN", "url": "https://github.com/username/repo/issues/3", "repository": {"name": "repo", "owner": {"login": "username"}}, "body": "- [ ] todo"}]}}}}}`)
+	})
+	defer teardown()
+
+	issue := &issues2markdown.Issue{Number: 1, Organization: "username", Repository: "repo"}
+	subIssues, err := provider.SubIssues(context.Background(), issue, &issues2markdown.SearchOptions{IncludeBody: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subIssues) != 1 || subIssues[0].Number != 3 || subIssues[0].Body != "- [ ] todo" {
+		t.Fatalf("Expected the sub-issue #3 but got %+v", subIssues)
+	}
+}
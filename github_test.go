@@ -0,0 +1,477 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+func TestGithubProviderAuthorize(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `{"login": "username"}`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "username" {
+		t.Fatalf("Expected login %q but got %q", "username", user.Login)
+	}
+}
+
+func TestGithubProviderSearch(t *testing.T) {
+	client, mux, serverURL, teardown := providerSetup(t)
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.Query().Get("q"); got != "type:issue is:open" {
+			t.Errorf("Expected query %q but got %q", "type:issue is:open", got)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/search/issues?q=type%%3Aissue+is%%3Aopen&page=2>; rel="next"`, serverURL))
+			fmt.Fprint(w, `{"total_count": 2, "items": [{"number": 1, "title": "Issue title 1", "state": "open", "url": "https://api.github.com/repos/username/repo/issues/1", "html_url": "https://github.com/username/repo/issues/1"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"total_count": 2, "items": [{"number": 2, "title": "Issue title 2", "state": "open", "url": "https://api.github.com/repos/username/repo/issues/2", "html_url": "https://github.com/username/repo/issues/2"}]}`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	result, err := provider.Search(context.Background(), "type:issue is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Number != 1 {
+		t.Fatalf("Expected issue #1 in the first page but got %+v", result.Issues)
+	}
+	if result.NextPage != "2" {
+		t.Fatalf("Expected next page %q but got %q", "2", result.NextPage)
+	}
+
+	result, err = provider.Search(context.Background(), "type:issue is:open", &issues2markdown.SearchOptions{Page: result.NextPage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Number != 2 {
+		t.Fatalf("Expected issue #2 in the second page but got %+v", result.Issues)
+	}
+	if result.NextPage != "" {
+		t.Fatalf("Expected no next page but got %q", result.NextPage)
+	}
+}
+
+func TestGithubEnterpriseProvider(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		webURL  string
+		apiPath string
+	}{
+		{"/", "", "/api/v3/"},
+		{"/api/v3", "", "/api/v3/"},
+		{"/github/api/v3/", "/github/", "/github/api/v3/"},
+	}
+	for _, test := range tests {
+		mux := http.NewServeMux()
+		server := httptest.NewServer(mux)
+		webURL := ""
+		if test.webURL != "" {
+			webURL = server.URL + test.webURL
+		}
+		htmlURL := server.URL + test.webURL
+		if test.webURL == "" {
+			htmlURL = server.URL + "/"
+		}
+		mux.HandleFunc(test.apiPath+"user", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			fmt.Fprint(w, `{"login": "username"}`)
+		})
+		mux.HandleFunc(test.apiPath+"search/issues", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			fmt.Fprintf(w, `{"total_count": 1, "items": [{"number": 1, "title": "Issue title 1", "state": "closed", "url": "%s%srepos/organization/repository/issues/1", "html_url": "%sorganization/repository/issues/1"}]}`, server.URL, test.apiPath, htmlURL)
+		})
+
+		provider, err := issues2markdown.NewGithubEnterpriseProvider(nil, server.URL+test.baseURL, "", webURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+		if err != nil {
+			t.Fatal(err)
+		}
+		issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "is:closed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		markdown, err := i2md.Render(issues, issues2markdown.NewRenderOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedMarkdown := fmt.Sprintf("- [x] organization/repository : [#1 Issue title 1](%sorganization/repository/issues/1)", htmlURL)
+		if markdown != expectedMarkdown {
+			t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+		}
+		server.Close()
+	}
+}
+
+func TestGithubProviderSearchIssueFields(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `{"total_count": 1, "items": [{"number": 1, "title": "Issue title 1", "state": "open", "url": "https://api.github.com/repos/username/repo/issues/1", "html_url": "https://github.com/username/repo/issues/1",
+			"user": {"login": "username"},
+			"assignees": [{"login": "octocat"}, {"login": "hubot"}],
+			"labels": [{"name": "bug", "color": "d73a4a"}, {"name": "good first issue", "color": "7057ff"}],
+			"milestone": {"title": "v1.0", "due_on": "2018-10-09T07:00:00Z"},
+			"created_at": "2018-10-01T10:00:00Z", "updated_at": "2018-10-02T10:00:00Z", "closed_at": "2018-10-03T10:00:00Z"}]}`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	result, err := provider.Search(context.Background(), "type:issue is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 {
+		t.Fatalf("Expected %d issues but got %d", 1, len(result.Issues))
+	}
+	dueOn := time.Date(2018, 10, 9, 7, 0, 0, 0, time.UTC)
+	testIssueFields(t, result.Issues[0], issues2markdown.Issue{
+		Author:    issues2markdown.User{Login: "username"},
+		Assignees: []issues2markdown.User{{Login: "octocat"}, {Login: "hubot"}},
+		Labels:    []issues2markdown.Label{{Name: "bug", Color: "d73a4a"}, {Name: "good first issue", Color: "7057ff"}},
+		Milestone: &issues2markdown.Milestone{Title: "v1.0", DueOn: &dueOn},
+	})
+	testIssueTimes(t, result.Issues[0], "2018-10-01T10:00:00Z", "2018-10-02T10:00:00Z", "2018-10-03T10:00:00Z")
+}
+
+func TestGithubProviderSearchIssueWithoutFields(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `{"total_count": 1, "items": [{"number": 1, "title": "Issue title 1", "state": "open", "url": "https://api.github.com/repos/username/repo/issues/1", "user": {"login": "username"}}]}`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	result, err := provider.Search(context.Background(), "type:issue is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testIssueFields(t, result.Issues[0], issues2markdown.Issue{
+		Author: issues2markdown.User{Login: "username"},
+	})
+	testIssueTimes(t, result.Issues[0], "0001-01-01T00:00:00Z", "0001-01-01T00:00:00Z", "")
+}
+
+func TestGithubProviderSearchIssueType(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `{"total_count": 1, "items": [{"number": 1, "title": "Issue title 1", "state": "open", "url": "https://api.github.com/repos/username/repo/issues/1", "type": {"name": "Bug"}}]}`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	result, err := provider.Search(context.Background(), "type:issue is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue := result.Issues[0]; issue.Type != "Bug" {
+		t.Fatalf("Expected type %q but got %q", "Bug", issue.Type)
+	}
+}
+
+func TestGithubProviderSearchReactions(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `{"total_count": 1, "items": [{"number": 1, "title": "Issue title 1", "state": "open", "url": "https://api.github.com/repos/username/repo/issues/1", "comments": 7,
+			"reactions": {"total_count": 6, "+1": 3, "-1": 1, "laugh": 0, "hooray": 0, "confused": 0, "heart": 1, "rocket": 1, "eyes": 0}}]}`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	result, err := provider.Search(context.Background(), "type:issue is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := result.Issues[0]
+	if issue.CommentsCount != 7 {
+		t.Fatalf("Expected %d comments but got %d", 7, issue.CommentsCount)
+	}
+	expectedReactions := issues2markdown.Reactions{TotalCount: 6, PlusOne: 3, MinusOne: 1, Heart: 1, Rocket: 1}
+	if issue.Reactions != expectedReactions {
+		t.Fatalf("Expected reactions %+v but got %+v", expectedReactions, issue.Reactions)
+	}
+}
+
+func TestGithubProviderSearchPullRequests(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.Query().Get("q"); got != "type:pr is:open" {
+			t.Errorf("Expected query %q but got %q", "type:pr is:open", got)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		pullRequest := `{"number": %[1]d, "title": "Pull request title %[1]d", "state": "%[2]s", "url": "https://api.github.com/repos/username/repo/issues/%[1]d", "html_url": "https://github.com/username/repo/pull/%[1]d", "draft": %[3]v, "pull_request": {"url": "https://api.github.com/repos/username/repo/pulls/%[1]d", "merged_at": %[4]s}}`
+		fmt.Fprintf(w, `{"total_count": 2, "items": [%s, %s]}`, fmt.Sprintf(pullRequest, 1, "open", true, "null"), fmt.Sprintf(pullRequest, 2, "closed", false, `"2018-10-09T10:00:00Z"`))
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	result, err := provider.Search(context.Background(), "type:pr is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 2 {
+		t.Fatalf("Expected %d pull requests but got %d", 2, len(result.Issues))
+	}
+	draft := result.Issues[0]
+	if !draft.IsPullRequest || !draft.Draft || draft.Merged || draft.ReviewState != "" {
+		t.Fatalf("Expected a draft pull request but got %+v", draft)
+	}
+	merged := result.Issues[1]
+	if !merged.IsPullRequest || merged.Draft || !merged.Merged || merged.ReviewState != "" {
+		t.Fatalf("Expected a merged pull request but got %+v", merged)
+	}
+}
+
+func TestGithubProviderReviewState(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	reviews := map[int][]string{
+		1: {`[{"user": {"login": "octocat"}, "state": "CHANGES_REQUESTED"}]`, `[{"user": {"login": "hubot"}, "state": "APPROVED"}]`},
+		2: {`[{"user": {"login": "octocat"}, "state": "CHANGES_REQUESTED"}, {"user": {"login": "octocat"}, "state": "COMMENTED"}]`, `[{"user": {"login": "octocat"}, "state": "APPROVED"}]`},
+	}
+	for number := range reviews {
+		number := number
+		mux.HandleFunc(fmt.Sprintf("/repos/username/repo/pulls/%d/reviews", number), func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			// the last reviews are on the second page
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, r.URL.Path))
+				fmt.Fprint(w, reviews[number][0])
+				return
+			}
+			fmt.Fprint(w, reviews[number][1])
+		})
+	}
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	expectedStates := map[int]string{
+		1: issues2markdown.ReviewChangesRequested,
+		2: issues2markdown.ReviewApproved,
+	}
+	for number, expectedState := range expectedStates {
+		issue := &issues2markdown.Issue{Number: number, IsPullRequest: true, URL: fmt.Sprintf("https://api.github.com/repos/username/repo/issues/%d", number)}
+		state, err := provider.ReviewState(context.Background(), issue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state != expectedState {
+			t.Fatalf("Expected review state %q for #%d but got %q", expectedState, number, state)
+		}
+	}
+}
+
+func TestGithubProviderComments(t *testing.T) {
+	client, mux, serverURL, teardown := providerSetup(t)
+	mux.HandleFunc("/repos/username/repo/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/username/repo/issues/1/comments?page=2>; rel="next"`, serverURL))
+			fmt.Fprint(w, `[{"body": "First comment", "user": {"login": "octocat"}, "html_url": "https://github.com/username/repo/issues/1#issuecomment-1", "created_at": "2018-10-01T10:00:00Z"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"body": "Second comment", "user": {"login": "username"}, "html_url": "https://github.com/username/repo/issues/1#issuecomment-2", "created_at": "2018-10-02T10:00:00Z"}]`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	issue := &issues2markdown.Issue{Number: 1, URL: "https://api.github.com/repos/username/repo/issues/1"}
+	comments, err := provider.Comments(context.Background(), issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []issues2markdown.Comment{
+		{
+			Author:    issues2markdown.User{Login: "octocat"},
+			Body:      "First comment",
+			HTMLURL:   "https://github.com/username/repo/issues/1#issuecomment-1",
+			CreatedAt: time.Date(2018, 10, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Author:    issues2markdown.User{Login: "username"},
+			Body:      "Second comment",
+			HTMLURL:   "https://github.com/username/repo/issues/1#issuecomment-2",
+			CreatedAt: time.Date(2018, 10, 2, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	if !reflect.DeepEqual(comments, expected) {
+		t.Fatalf("Expected comments %+v but got %+v", expected, comments)
+	}
+}
+
+func TestGithubProviderTimeline(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/repos/username/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `[{"event": "labeled", "actor": {"login": "octocat"}, "created_at": "2018-10-01T10:00:00Z", "label": {"name": "bug", "color": "d73a4a"}},
+			{"event": "cross-referenced", "actor": {"login": "hubot"}, "created_at": "2018-10-02T10:00:00Z", "source": {"type": "issue", "issue": {"html_url": "https://github.com/username/other/issues/7"}}},
+			{"event": "closed", "actor": {"login": "username"}, "created_at": "2018-10-03T10:00:00Z", "commit_id": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}]`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	issue := &issues2markdown.Issue{Number: 1, URL: "https://api.github.com/repos/username/repo/issues/1"}
+	events, err := provider.Timeline(context.Background(), issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []issues2markdown.Event{
+		{Type: "labeled", Actor: issues2markdown.User{Login: "octocat"}, CreatedAt: time.Date(2018, 10, 1, 10, 0, 0, 0, time.UTC), Label: "bug"},
+		{Type: "cross-referenced", Actor: issues2markdown.User{Login: "hubot"}, CreatedAt: time.Date(2018, 10, 2, 10, 0, 0, 0, time.UTC), SourceURL: "https://github.com/username/other/issues/7"},
+		{Type: "closed", Actor: issues2markdown.User{Login: "username"}, CreatedAt: time.Date(2018, 10, 3, 10, 0, 0, 0, time.UTC), CommitID: "6dcb09b5b57875f334f61aebed695e2e4193db5e"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Expected events %+v but got %+v", expected, events)
+	}
+}
+
+func TestGithubProviderLinkedPullRequests(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/repos/username/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `[{"event": "cross-referenced", "source": {"type": "issue", "issue": {"number": 7, "title": "Other issue", "state": "open", "html_url": "https://github.com/username/other/issues/7", "body": "Fixes username/repo#1",
+				"repository": {"name": "other", "owner": {"login": "username"}}}}},
+			{"event": "cross-referenced", "source": {"type": "issue", "issue": {"number": 8, "title": "Fix the bug", "state": "closed", "html_url": "https://github.com/username/repo/pull/8", "body": "This fixes #1.",
+				"pull_request": {"merged_at": "2018-10-02T10:00:00Z"}, "repository": {"name": "repo", "owner": {"login": "username"}}}}},
+			{"event": "cross-referenced", "source": {"type": "issue", "issue": {"number": 3, "title": "Refactor", "state": "open", "html_url": "https://github.com/username/lib/pull/3", "body": "Related to username/repo#1, closes #1", "draft": true,
+				"pull_request": {"merged_at": null}, "repository": {"name": "lib", "owner": {"login": "username"}}}}},
+			{"event": "cross-referenced", "source": {"type": "issue", "issue": {"number": 9, "title": "Other fix", "state": "open", "html_url": "https://github.com/username/lib/pull/9", "body": "Resolves https://github.com/username/repo/issues/1",
+				"pull_request": {"merged_at": null}, "repository": {"name": "lib", "owner": {"login": "username"}}}}},
+			{"event": "closed", "commit_id": "6dcb09b5b57875f334f61aebed695e2e4193db5e"}]`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	issue := &issues2markdown.Issue{Number: 1, URL: "https://api.github.com/repos/username/repo/issues/1"}
+	pullRequests, err := provider.LinkedPullRequests(context.Background(), issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []issues2markdown.LinkedPullRequest{
+		{Number: 8, Title: "Fix the bug", State: "closed", HTMLURL: "https://github.com/username/repo/pull/8", Organization: "username", Repository: "repo", Merged: true, Closes: true},
+		{Number: 3, Title: "Refactor", State: "open", HTMLURL: "https://github.com/username/lib/pull/3", Organization: "username", Repository: "lib", Draft: true},
+		{Number: 9, Title: "Other fix", State: "open", HTMLURL: "https://github.com/username/lib/pull/9", Organization: "username", Repository: "lib", Closes: true},
+	}
+	if !reflect.DeepEqual(pullRequests, expected) {
+		t.Fatalf("Expected pull requests %+v but got %+v", expected, pullRequests)
+	}
+}
+
+func TestGithubProviderSubIssues(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/repos/username/repo/issues/1/sub_issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `[{"number": 2, "title": "Issue title 2", "state": "open", "url": "https://api.github.com/repos/username/repo/issues/2", "type": {"name": "Task"}},
+			{"number": 7, "title": "Issue title 7", "state": "closed", "url": "https://api.github.com/repos/username/other/issues/7"}]`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	issue := &issues2markdown.Issue{Number: 1, URL: "https://api.github.com/repos/username/repo/issues/1"}
+	subIssues, err := provider.SubIssues(context.Background(), issue, &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subIssues) != 2 {
+		t.Fatalf("Expected %d sub-issues but got %d", 2, len(subIssues))
+	}
+	if subIssues[0].Number != 2 || subIssues[0].Type != "Task" {
+		t.Fatalf("Expected the task #2 but got %+v", subIssues[0])
+	}
+	if repository, _ := subIssues[1].GetRepository(); subIssues[1].Number != 7 || repository != "other" {
+		t.Fatalf("Expected #7 of the other repository but got %+v", subIssues[1])
+	}
+}
+
+func TestGithubProviderTeams(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/user/teams", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `[{"slug": "backend", "organization": {"login": "organization"}},
+			{"slug": "frontend", "organization": {"login": "organization"}}]`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	teams, err := provider.Teams(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTeams := []string{"organization/backend", "organization/frontend"}
+	if !reflect.DeepEqual(teams, expectedTeams) {
+		t.Fatalf("Expected teams %q but got %q", expectedTeams, teams)
+	}
+}
+
+func TestGithubProviderSearchTotal(t *testing.T) {
+	client, mux, _, teardown := providerSetup(t)
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		fmt.Fprint(w, `{"total_count": 2500, "incomplete_results": true, "items": [{"number": 1, "title": "Issue title 1", "state": "open", "url": "https://api.github.com/repos/username/repo/issues/1"}]}`)
+	})
+	defer teardown()
+
+	provider := issues2markdown.NewGithubProvider(client)
+	result, err := provider.Search(context.Background(), "type:issue is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2500 || !result.Incomplete {
+		t.Fatalf("Expected %d incomplete results but got %d (incomplete %v)", 2500, result.Total, result.Incomplete)
+	}
+	if limit := provider.SearchLimit(); limit != 1000 {
+		t.Fatalf("Expected a search limit of %d but got %d", 1000, limit)
+	}
+}
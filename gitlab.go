@@ -0,0 +1,360 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultGitlabBaseURL is the base URL of gitlab.com
+	DefaultGitlabBaseURL = "https://gitlab.com/"
+)
+
+// GitlabProvider is the IssueProvider for the Gitlab REST API
+type GitlabProvider struct {
+	client  *http.Client
+	BaseURL *url.URL
+	Token   string
+}
+
+// NewGitlabProvider creates a GitlabProvider instance for the Gitlab instance
+// at baseURL, DefaultGitlabBaseURL if empty, authenticated with a personal
+// access token.
+//
+// If the provided httpClient is nil http.DefaultClient is used. When the
+// httpClient already handles the authentication the token can be empty.
+func NewGitlabProvider(httpClient *http.Client, baseURL string, token string) (*GitlabProvider, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = DefaultGitlabBaseURL
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	parsedBaseURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	provider := &GitlabProvider{
+		client:  httpClient,
+		BaseURL: parsedBaseURL,
+		Token:   token,
+	}
+	return provider, nil
+}
+
+// gitlabUser represents a Gitlab user
+type gitlabUser struct {
+	Username string `json:"username"`
+}
+
+// gitlabIssue represents a Gitlab issue
+type gitlabIssue struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	WebURL string `json:"web_url"`
+	Links  struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	References struct {
+		Full string `json:"full"`
+	} `json:"references"`
+	Author    gitlabUser   `json:"author"`
+	Assignees []gitlabUser `json:"assignees"`
+	Labels    []struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title   string `json:"title"`
+		DueDate string `json:"due_date"`
+	} `json:"milestone"`
+	Description    string     `json:"description"`
+	UserNotesCount int        `json:"user_notes_count"`
+	Upvotes        int        `json:"upvotes"`
+	Downvotes      int        `json:"downvotes"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ClosedAt       *time.Time `json:"closed_at"`
+}
+
+// Authorize gets authentication information
+func (gp *GitlabProvider) Authorize(ctx context.Context) (*User, error) {
+	req, err := gp.newRequest("api/v4/user")
+	if err != nil {
+		return nil, err
+	}
+	user := &gitlabUser{}
+	_, err = doJSON(ctx, gp.client, req, user)
+	if err != nil {
+		return nil, err
+	}
+	result := &User{
+		Login: user.Username,
+	}
+	return result, nil
+}
+
+// Search returns a page of the Issues that match the query
+//
+// The query qualifiers are translated to the parameters of the Gitlab issues
+// list API. The repo: qualifier lists the issues of a project and the org:
+// qualifier the issues of a group, all the issues visible to the user are
+// listed otherwise. The is:, state:, author:, assignee:, label:, milestone:
+// and free text terms filter the list. The user: qualifier is unsupported, the
+// Gitlab users have no issues list.
+func (gp *GitlabProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	var req *http.Request
+	var err error
+	if strings.HasPrefix(options.Page, gp.BaseURL.String()) {
+		// the page is the next link of a previous response
+		req, err = gp.newRequest(options.Page)
+	} else {
+		req, err = gp.newSearchRequest(query, options.Page)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []gitlabIssue
+	response, err := doJSON(ctx, gp.client, req, &issues)
+	if err != nil {
+		return nil, err
+	}
+
+	// process page results
+	result := &SearchResult{}
+	for _, v := range issues {
+		result.Issues = append(result.Issues, newIssueFromGitlab(&v))
+	}
+
+	// process pagination
+	if next := response.Header.Get("X-Next-Page"); next != "" {
+		result.NextPage = next
+	} else {
+		result.NextPage = nextLink(response)
+	}
+	return result, nil
+}
+
+// newRequest creates a GET request for the API path, relative to BaseURL
+func (gp *GitlabProvider) newRequest(path string) (*http.Request, error) {
+	u, err := gp.BaseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if gp.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", gp.Token)
+	}
+	return req, nil
+}
+
+// newSearchRequest creates the issues list request that matches the query
+func (gp *GitlabProvider) newSearchRequest(query string, page string) (*http.Request, error) {
+	terms := parseSearchTerms(query)
+	params := url.Values{}
+
+	// the issues list endpoint depends on the scope of the query
+	path := "api/v4/issues"
+	params.Set("scope", "all")
+	if repo := terms.get("repo"); repo != "" {
+		path = "api/v4/projects/" + url.PathEscape(repo) + "/issues"
+		params.Del("scope")
+	} else if group := terms.get("org"); group != "" {
+		path = "api/v4/groups/" + url.PathEscape(group) + "/issues"
+		params.Del("scope")
+	}
+	terms.remove("repo")
+	terms.remove("org")
+
+	switch terms.state() {
+	case "open":
+		params.Set("state", "opened")
+	case "closed":
+		params.Set("state", "closed")
+	}
+	terms.remove("is", "open", "closed", "issue")
+	terms.remove("state", "open", "closed")
+
+	if author := terms.get("author"); author != "" {
+		params.Set("author_username", author)
+	}
+	terms.remove("author")
+	if assignee := terms.get("assignee"); assignee != "" {
+		params.Set("assignee_username", assignee)
+	}
+	terms.remove("assignee")
+	if milestone := terms.get("milestone"); milestone != "" {
+		params.Set("milestone", milestone)
+	}
+	terms.remove("milestone")
+	if labels := terms.qualifiers["label"]; len(labels) > 0 {
+		params.Set("labels", strings.Join(labels, ","))
+	}
+	terms.remove("label")
+	if labels := terms.qualifiers["-label"]; len(labels) > 0 {
+		params.Set("not[labels]", strings.Join(labels, ","))
+	}
+	terms.remove("-label")
+	if len(terms.text) > 0 {
+		params.Set("search", strings.Join(terms.text, " "))
+	}
+
+	// Gitlab issues lists only contain issues of non archived projects
+	terms.remove("type", "issue")
+	terms.remove("archived", "false")
+	if err := terms.unsupported("gitlab"); err != nil {
+		return nil, err
+	}
+
+	params.Set("per_page", "100")
+	// without the details the labels are names only, without colors
+	params.Set("with_labels_details", "true")
+	if page != "" {
+		if _, err := strconv.Atoi(page); err != nil {
+			return nil, err
+		}
+		params.Set("page", page)
+	}
+
+	req, err := gp.newRequest(path)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = params.Encode()
+	return req, nil
+}
+
+// newIssueFromGitlab creates an Issue from a Gitlab issue
+func newIssueFromGitlab(v *gitlabIssue) Issue {
+	item := Issue{
+		Number:  v.IID,
+		Title:   v.Title,
+		State:   v.State,
+		URL:     v.Links.Self,
+		HTMLURL: v.WebURL,
+	}
+	// Gitlab uses opened instead of open
+	if v.State == "opened" {
+		item.State = "open"
+	}
+	item.Author.Login = v.Author.Username
+	item.Body = v.Description
+	item.CommentsCount = v.UserNotesCount
+	// Gitlab counts the thumbs up and down award emojis as votes
+	item.Reactions = Reactions{
+		TotalCount: v.Upvotes + v.Downvotes,
+		PlusOne:    v.Upvotes,
+		MinusOne:   v.Downvotes,
+	}
+	item.CreatedAt = v.CreatedAt
+	item.UpdatedAt = v.UpdatedAt
+	item.ClosedAt = v.ClosedAt
+	for _, assignee := range v.Assignees {
+		item.Assignees = append(item.Assignees, User{Login: assignee.Username})
+	}
+	for _, label := range v.Labels {
+		item.Labels = append(item.Labels, Label{Name: label.Name, Color: strings.TrimPrefix(label.Color, "#")})
+	}
+	if v.Milestone != nil {
+		item.Milestone = &Milestone{
+			Title: v.Milestone.Title,
+			DueOn: parseDate(v.Milestone.DueDate),
+		}
+	}
+
+	// the project path is the full reference without the issue number or the
+	// web URL path up to the issues segment
+	path := v.References.Full
+	if idx := strings.LastIndex(path, "#"); idx >= 0 {
+		path = path[:idx]
+	}
+	if path == "" {
+		if u, err := url.Parse(v.WebURL); err == nil {
+			path = strings.Trim(u.Path, "/")
+			if idx := strings.Index(path, "/-/issues/"); idx >= 0 {
+				path = path[:idx]
+			} else if idx := strings.LastIndex(path, "/issues/"); idx >= 0 {
+				path = path[:idx]
+			}
+		}
+	}
+	if idx := strings.LastIndex(path, "/"); idx >= 0 {
+		item.Organization = path[:idx]
+		item.Repository = path[idx+1:]
+	}
+	return item
+}
+
+// gitlabNote represents a Gitlab note, a comment or a system note of an event
+type gitlabNote struct {
+	ID        int        `json:"id"`
+	Body      string     `json:"body"`
+	Author    gitlabUser `json:"author"`
+	System    bool       `json:"system"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Comments returns all the comments of the Issue, oldest first. The system
+// notes of the events aren't comments.
+func (gp *GitlabProvider) Comments(ctx context.Context, issue *Issue) ([]Comment, error) {
+	organization, repository, err := issueRepository(issue)
+	if err != nil {
+		return nil, err
+	}
+	path := "api/v4/projects/" + url.PathEscape(organization+"/"+repository) + "/issues/" + strconv.Itoa(issue.Number) +
+		"/notes?sort=asc&order_by=created_at&per_page=100"
+	var result []Comment
+	for path != "" {
+		req, err := gp.newRequest(path)
+		if err != nil {
+			return nil, err
+		}
+		var notes []gitlabNote
+		response, err := doJSON(ctx, gp.client, req, &notes)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range notes {
+			if v.System {
+				continue
+			}
+			result = append(result, Comment{
+				Author:    User{Login: v.Author.Username},
+				Body:      v.Body,
+				HTMLURL:   issue.HTMLURL + "#note_" + strconv.Itoa(v.ID),
+				CreatedAt: v.CreatedAt,
+			})
+		}
+		path = nextLink(response)
+	}
+	return result, nil
+}
@@ -0,0 +1,303 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// gitlabSetup sets up a test HTTP server along with a GitlabProvider that is
+// configured to talk to that test server.
+func gitlabSetup(t *testing.T) (provider *issues2markdown.GitlabProvider, mux *http.ServeMux, serverURL string, teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	provider, err := issues2markdown.NewGitlabProvider(nil, server.URL, "gitlab_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, mux, server.URL, server.Close
+}
+
+func TestGitlabProviderAuthorize(t *testing.T) {
+	provider, mux, _, teardown := gitlabSetup(t)
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "gitlab_token" {
+			t.Errorf("Expected token %q but got %q", "gitlab_token", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"username": "username"}`)
+	})
+	defer teardown()
+
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "username" {
+		t.Fatalf("Expected login %q but got %q", "username", user.Login)
+	}
+}
+
+func TestGitlabProviderAuthorizeUnauthorized(t *testing.T) {
+	provider, mux, _, teardown := gitlabSetup(t)
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"401 Unauthorized"}`, 401)
+	})
+	defer teardown()
+
+	_, err := provider.Authorize(context.Background())
+	errResponse, ok := err.(*issues2markdown.ErrorResponse)
+	if !ok {
+		t.Fatalf("Expected an ErrorResponse but got %v", err)
+	}
+	if errResponse.Response.StatusCode != 401 {
+		t.Fatalf("Expected status code %d but got %d", 401, errResponse.Response.StatusCode)
+	}
+}
+
+func TestGitlabProviderSearchGroup(t *testing.T) {
+	provider, mux, _, teardown := gitlabSetup(t)
+	mux.HandleFunc("/api/v4/groups/group/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		expected := map[string]string{
+			"state":           "opened",
+			"author_username": "username",
+			"labels":          "bug,good first issue",
+			"search":          "crash",
+			"page":            "",
+		}
+		for k, v := range expected {
+			if got := r.URL.Query().Get(k); got != v {
+				t.Errorf("Expected parameter %s=%q but got %q", k, v, got)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Next-Page", "2")
+		fmt.Fprint(w, `[{"iid": 1, "title": "Issue title 1", "state": "opened", "web_url": "https://gitlab.example.com/group/subgroup/project/-/issues/1", "_links": {"self": "https://gitlab.example.com/api/v4/projects/5/issues/1"}, "references": {"full": "group/subgroup/project#1"}}]`)
+	})
+	defer teardown()
+
+	query := `type:issue is:open org:group author:username label:bug label:"good first issue" crash archived:false`
+	result, err := provider.Search(context.Background(), query, &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NextPage != "2" {
+		t.Fatalf("Expected next page %q but got %q", "2", result.NextPage)
+	}
+	if len(result.Issues) != 1 {
+		t.Fatalf("Expected %d issues but got %d", 1, len(result.Issues))
+	}
+	issue := result.Issues[0]
+	if issue.State != "open" {
+		t.Fatalf("Expected state %q but got %q", "open", issue.State)
+	}
+	organization, _ := issue.GetOrganization()
+	if organization != "group/subgroup" {
+		t.Fatalf("Expected organization %q but got %q", "group/subgroup", organization)
+	}
+	repository, _ := issue.GetRepository()
+	if repository != "project" {
+		t.Fatalf("Expected repository %q but got %q", "project", repository)
+	}
+}
+
+func TestGitlabProviderSearchLinkPagination(t *testing.T) {
+	provider, mux, serverURL, teardown := gitlabSetup(t)
+	mux.HandleFunc("/api/v4/projects/group%2Fproject/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.RawPath; got != "/api/v4/projects/group%2Fproject/issues" {
+			t.Errorf("Expected project path %q but got %q", "/api/v4/projects/group%2Fproject/issues", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("cursor") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v4/projects/group%%2Fproject/issues?cursor=abc>; rel="next"`, serverURL))
+			fmt.Fprint(w, `[{"iid": 1, "title": "Issue title 1", "state": "closed", "web_url": "https://gitlab.example.com/group/project/-/issues/1"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"iid": 2, "title": "Issue title 2", "state": "opened", "web_url": "https://gitlab.example.com/group/project/-/issues/2"}]`)
+	})
+	defer teardown()
+
+	options := &issues2markdown.SearchOptions{}
+	result, err := provider.Search(context.Background(), "repo:group/project", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Number != 1 {
+		t.Fatalf("Expected issue #1 in the first page but got %+v", result.Issues)
+	}
+	options.Page = result.NextPage
+	result, err = provider.Search(context.Background(), "repo:group/project", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Number != 2 {
+		t.Fatalf("Expected issue #2 in the second page but got %+v", result.Issues)
+	}
+	if result.NextPage != "" {
+		t.Fatalf("Expected no next page but got %q", result.NextPage)
+	}
+}
+
+func TestGitlabProviderSearchUnsupportedQualifier(t *testing.T) {
+	provider, _, _, teardown := gitlabSetup(t)
+	defer teardown()
+
+	for _, query := range []string{"is:pr", "user:username"} {
+		_, err := provider.Search(context.Background(), query, &issues2markdown.SearchOptions{})
+		if err == nil {
+			t.Fatalf("Expected an unsupported qualifier error for %q", query)
+		}
+	}
+}
+
+func TestGitlabProviderSearchUnsupportedOperators(t *testing.T) {
+	provider, _, _, teardown := gitlabSetup(t)
+	defer teardown()
+
+	for _, query := range []string{"is:open NOT wontfix", "label:a OR label:b"} {
+		_, err := provider.Search(context.Background(), query, &issues2markdown.SearchOptions{})
+		if err == nil || !strings.Contains(err.Error(), "unsupported search operator") {
+			t.Fatalf("Expected an unsupported operator error for %q but got %v", query, err)
+		}
+	}
+}
+
+func TestGitlabProviderRender(t *testing.T) {
+	provider, mux, _, teardown := gitlabSetup(t)
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"username": "username"}`)
+	})
+	mux.HandleFunc("/api/v4/issues", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"iid": 1, "title": "Issue title 1", "state": "opened", "web_url": "https://gitlab.example.com/group/subgroup/project/-/issues/1", "references": {"full": "group/subgroup/project#1"}},
+			{"iid": 2, "title": "Issue title 2", "state": "closed", "web_url": "https://gitlab.example.com/group/project/-/issues/2", "references": {"full": "group/project#2"}}]`)
+	})
+	defer teardown()
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "is:issue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := i2md.Render(issues, issues2markdown.NewRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMarkdown := `- [ ] group/subgroup/project : [#1 Issue title 1](https://gitlab.example.com/group/subgroup/project/-/issues/1)
+- [x] group/project : [#2 Issue title 2](https://gitlab.example.com/group/project/-/issues/2)`
+
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestGitlabProviderSearchIssueFields(t *testing.T) {
+	provider, mux, _, teardown := gitlabSetup(t)
+	mux.HandleFunc("/api/v4/issues", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.Query().Get("with_labels_details"); got != "true" {
+			t.Errorf("Expected parameter %s=%q but got %q", "with_labels_details", "true", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"iid": 1, "title": "Issue title 1", "state": "opened", "web_url": "https://gitlab.example.com/group/project/-/issues/1",
+			"author": {"username": "username"},
+			"assignees": [{"username": "octocat"}],
+			"labels": [{"name": "bug", "color": "#d9534f"}],
+			"milestone": {"title": "v1.0", "due_date": "2018-10-09"}}]`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 {
+		t.Fatalf("Expected %d issues but got %d", 1, len(result.Issues))
+	}
+	testIssueFields(t, result.Issues[0], issues2markdown.Issue{
+		Author:    issues2markdown.User{Login: "username"},
+		Assignees: []issues2markdown.User{{Login: "octocat"}},
+		Labels:    []issues2markdown.Label{{Name: "bug", Color: "d9534f"}},
+		Milestone: &issues2markdown.Milestone{Title: "v1.0", DueOn: date(2018, 10, 9)},
+	})
+}
+
+func TestGitlabProviderSearchVotes(t *testing.T) {
+	provider, mux, _, teardown := gitlabSetup(t)
+	mux.HandleFunc("/api/v4/issues", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"iid": 1, "title": "Issue title 1", "state": "opened", "web_url": "https://gitlab.example.com/group/project/-/issues/1",
+			"user_notes_count": 4, "upvotes": 5, "downvotes": 2}]`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := result.Issues[0]
+	if issue.CommentsCount != 4 {
+		t.Fatalf("Expected %d comments but got %d", 4, issue.CommentsCount)
+	}
+	expectedReactions := issues2markdown.Reactions{TotalCount: 7, PlusOne: 5, MinusOne: 2}
+	if issue.Reactions != expectedReactions {
+		t.Fatalf("Expected reactions %+v but got %+v", expectedReactions, issue.Reactions)
+	}
+}
+
+func TestGitlabProviderComments(t *testing.T) {
+	provider, mux, _, teardown := gitlabSetup(t)
+	mux.HandleFunc("/api/v4/projects/group%2Fproject/issues/1/notes", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.Query().Get("sort"); got != "asc" {
+			t.Errorf("Expected parameter %s=%q but got %q", "sort", "asc", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"id": 10, "body": "added ~bug label", "author": {"username": "octocat"}, "system": true},
+			{"id": 11, "body": "First comment", "author": {"username": "username"}, "system": false, "created_at": "2018-10-01T10:00:00Z"}]`)
+	})
+	defer teardown()
+
+	issue := &issues2markdown.Issue{Number: 1, HTMLURL: "https://gitlab.example.com/group/project/-/issues/1", Organization: "group", Repository: "project"}
+	comments, err := provider.Comments(context.Background(), issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 {
+		t.Fatalf("Expected %d comments but got %d", 1, len(comments))
+	}
+	comment := comments[0]
+	if comment.Body != "First comment" || comment.Author.Login != "username" || comment.HTMLURL != "https://gitlab.example.com/group/project/-/issues/1#note_11" {
+		t.Fatalf("Expected the first comment but got %+v", comment)
+	}
+}
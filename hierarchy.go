@@ -0,0 +1,159 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// maxHierarchyDepth is the maximum number of sub-issue levels expanded, like
+// the Github limit
+const maxHierarchyDepth = 8
+
+// IssueTreeItem is an Issue of the tree of sub-issues, in depth-first order
+type IssueTreeItem struct {
+	Issue
+	// Depth is 0 for the root Issues
+	Depth int
+	// ParentOutside is whether the Issue has a Parent that isn't in the
+	// tree, like a parent of another repository
+	ParentOutside bool
+}
+
+// expandHierarchy returns the issues followed by all their descendants that
+// aren't already in the list. The descendants know their Parent.
+func expandHierarchy(ctx context.Context, provider HierarchyProvider, issues []Issue, searchOptions *SearchOptions) ([]Issue, error) {
+	seen := map[string]bool{}
+	for i := range issues {
+		seen[treeKey(&issues[i])] = true
+	}
+	result := issues
+	level := make([]int, len(issues))
+	for i := range level {
+		level[i] = i
+	}
+	for depth := 0; depth < maxHierarchyDepth && len(level) > 0; depth++ {
+		var next []int
+		for _, idx := range level {
+			children, err := provider.SubIssues(ctx, &result[idx], searchOptions)
+			if err != nil {
+				return nil, fmt.Errorf("#%d: %v", result[idx].Number, err)
+			}
+			parent := newIssueReference(&result[idx])
+			var references []IssueReference
+			for _, child := range children {
+				if child.Parent == nil {
+					child.Parent = parent
+				}
+				references = append(references, *newIssueReference(&child))
+				key := treeKey(&child)
+				// the issues are expanded once, even in a cycle
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				result = append(result, child)
+				next = append(next, len(result)-1)
+			}
+			if len(result[idx].SubIssues) == 0 {
+				result[idx].SubIssues = references
+			}
+		}
+		level = next
+	}
+	return result, nil
+}
+
+// treeKey returns the key of an Issue in the tree of sub-issues
+func treeKey(issue *Issue) string {
+	organization, repository, _ := issueRepository(issue)
+	return issueKey(organization, repository, issue.Number)
+}
+
+// issueTree returns the issues ordered as a tree of sub-issues, each child
+// after its parent. The Parent and SubIssues of the issues build the tree,
+// the issues whose parent isn't in the list are roots. Every Issue is in the
+// tree once, the first Issue of a cycle found in the list is its root.
+func issueTree(issues []Issue) []IssueTreeItem {
+	index := map[string]int{}
+	for i := range issues {
+		index[treeKey(&issues[i])] = i
+	}
+	children := make([][]int, len(issues))
+	hasParent := make([]bool, len(issues))
+	addChild := func(parent int, child int) {
+		if parent == child || hasParent[child] {
+			return
+		}
+		hasParent[child] = true
+		children[parent] = append(children[parent], child)
+	}
+	for i := range issues {
+		if p := issues[i].Parent; p != nil {
+			if parent, ok := index[issueKey(p.Organization, p.Repository, p.Number)]; ok {
+				addChild(parent, i)
+			}
+		}
+	}
+	for i := range issues {
+		for _, s := range issues[i].SubIssues {
+			if child, ok := index[issueKey(s.Organization, s.Repository, s.Number)]; ok {
+				addChild(i, child)
+			}
+		}
+	}
+	// the children are in the order of the list
+	for i := range children {
+		sort.Ints(children[i])
+	}
+
+	var result []IssueTreeItem
+	visited := make([]bool, len(issues))
+	var walk func(i int, depth int)
+	walk = func(i int, depth int) {
+		visited[i] = true
+		item := IssueTreeItem{
+			Issue: issues[i],
+			Depth: depth,
+		}
+		if p := issues[i].Parent; p != nil {
+			_, inside := index[issueKey(p.Organization, p.Repository, p.Number)]
+			item.ParentOutside = !inside
+		}
+		result = append(result, item)
+		for _, child := range children[i] {
+			if !visited[child] {
+				walk(child, depth+1)
+			}
+		}
+	}
+	for i := range issues {
+		if !hasParent[i] && !visited[i] {
+			walk(i, 0)
+		}
+	}
+	// the issues of cycles have parents, but no root
+	for i := range issues {
+		if !visited[i] {
+			walk(i, 0)
+		}
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// fakeHierarchyProvider is a fakeProvider that also lists the sub-issues of
+// its issues
+type fakeHierarchyProvider struct {
+	fakeProvider
+	subIssues map[int][]issues2markdown.Issue
+	requested []int
+}
+
+func (fp *fakeHierarchyProvider) SubIssues(ctx context.Context, issue *issues2markdown.Issue, options *issues2markdown.SearchOptions) ([]issues2markdown.Issue, error) {
+	fp.requested = append(fp.requested, issue.Number)
+	return fp.subIssues[issue.Number], nil
+}
+
+func newTreeIssue(number int, repository string) issues2markdown.Issue {
+	issue := issues2markdown.Issue{
+		Number:       number,
+		Title:        fmt.Sprintf("Issue title %d", number),
+		State:        "open",
+		HTMLURL:      fmt.Sprintf("https://github.com/username/%s/issues/%d", repository, number),
+		Organization: "username",
+		Repository:   repository,
+	}
+	return issue
+}
+
+func newTreeReference(number int, repository string) *issues2markdown.IssueReference {
+	reference := &issues2markdown.IssueReference{
+		Number:       number,
+		Organization: "username",
+		Repository:   repository,
+	}
+	return reference
+}
+
+func TestQueryExpandHierarchy(t *testing.T) {
+	provider := &fakeHierarchyProvider{
+		subIssues: map[int][]issues2markdown.Issue{
+			1: {newTreeIssue(2, "repo"), newTreeIssue(3, "other")},
+			2: {newTreeIssue(4, "repo")},
+			// a cycle back to the root
+			4: {newTreeIssue(1, "repo")},
+		},
+	}
+	provider.user = &issues2markdown.User{Login: "username"}
+	provider.issues = []issues2markdown.Issue{newTreeIssue(1, "repo")}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	options.ExpandHierarchy = true
+	issues, err := i2md.Query(options, "repo:username/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedNumbers := []int{1, 2, 3, 4}
+	if len(issues) != len(expectedNumbers) {
+		t.Fatalf("Expected %d issues but got %+v", len(expectedNumbers), issues)
+	}
+	for i, number := range expectedNumbers {
+		if issues[i].Number != number {
+			t.Fatalf("Expected issue #%d at %d but got #%d", number, i, issues[i].Number)
+		}
+	}
+	if len(issues[0].SubIssues) != 2 || issues[0].SubIssues[1].Repository != "other" {
+		t.Fatalf("Expected the sub-issues of #1 but got %+v", issues[0].SubIssues)
+	}
+	if parent := issues[3].Parent; parent == nil || parent.Number != 2 || parent.Repository != "repo" {
+		t.Fatalf("Expected #2 as the parent of #4 but got %+v", parent)
+	}
+	if len(provider.requested) != 4 {
+		t.Fatalf("Expected the sub-issues of each issue once but got %v", provider.requested)
+	}
+}
+
+func TestQueryExpandHierarchyUnsupportedProvider(t *testing.T) {
+	provider := &fakeProvider{user: &issues2markdown.User{Login: "username"}}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	options.ExpandHierarchy = true
+	if _, err := i2md.Query(options, "is:open"); err == nil {
+		t.Fatal("Expected an error for a provider without sub-issues")
+	}
+}
+
+func TestRenderIssueTree(t *testing.T) {
+	epic := newTreeIssue(1, "repo")
+	epic.SubIssues = []issues2markdown.IssueReference{*newTreeReference(3, "other"), *newTreeReference(2, "repo")}
+	task := newTreeIssue(2, "repo")
+	subTask := newTreeIssue(4, "repo")
+	subTask.Parent = newTreeReference(2, "repo")
+	subTask.State = "closed"
+	crossRepo := newTreeIssue(3, "other")
+	crossRepo.Parent = newTreeReference(1, "repo")
+	orphan := newTreeIssue(5, "repo")
+	orphan.Parent = newTreeReference(9, "elsewhere")
+	// #6 and #7 are parents of each other
+	cycleA := newTreeIssue(6, "repo")
+	cycleA.Parent = newTreeReference(7, "repo")
+	cycleB := newTreeIssue(7, "repo")
+	cycleB.Parent = newTreeReference(6, "repo")
+	issues := []issues2markdown.Issue{subTask, epic, task, crossRepo, orphan, cycleA, cycleB}
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(&fakeProvider{user: &issues2markdown.User{Login: "username"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewRenderOptions()
+	options.TemplateSource = issues2markdown.DefaultIssueTreeTemplate
+	markdown, err := i2md.Render(issues, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMarkdown := `- [ ] username/repo : [#1 Issue title 1](https://github.com/username/repo/issues/1)
+  - [ ] username/repo : [#2 Issue title 2](https://github.com/username/repo/issues/2)
+    - [x] username/repo : [#4 Issue title 4](https://github.com/username/repo/issues/4)
+  - [ ] username/other : [#3 Issue title 3](https://github.com/username/other/issues/3)
+- [ ] username/repo : [#5 Issue title 5](https://github.com/username/repo/issues/5) (sub-issue of username/elsewhere#9)
+- [ ] username/repo : [#6 Issue title 6](https://github.com/username/repo/issues/6)
+  - [ ] username/repo : [#7 Issue title 7](https://github.com/username/repo/issues/7)`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
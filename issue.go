@@ -18,10 +18,22 @@
 package issues2markdown
 
 import (
+	"fmt"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// taskRe matches the task list items of a Markdown body, the submatch is the
+// checkbox mark
+var taskRe = regexp.MustCompile(`^\s*(?:[-+*]|\d+[.)])\s+\[([ xX])\](?:\s|$)`)
+
+// closingRe matches the Github closing keywords of a pull request body, the
+// submatch is the reference of the closed issue: #N, ORG/REPO#N or its URL
+var closingRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+(\S+)`)
+
 // Issue represents an Issue from the provider
 type Issue struct {
 	Number  int
@@ -29,6 +41,138 @@ type Issue struct {
 	State   string
 	URL     string
 	HTMLURL string
+	// Organization and Repository are set by the providers whose URLs don't
+	// follow the Github API layout
+	Organization string
+	Repository   string
+	// Source is the name of the provider of the Issue when the results of
+	// several providers are merged
+	Source string
+	// Author is the user who opened the Issue
+	Author    User
+	Assignees []User
+	Labels    []Label
+	// Milestone is nil when the Issue has no milestone
+	Milestone *Milestone
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// ClosedAt is nil when the Issue is open or the provider doesn't know
+	// when it was closed
+	ClosedAt *time.Time
+	// IsPullRequest, Draft, Merged and ReviewState are only set for pull
+	// requests. ReviewState is one of the ReviewState constants, set by
+	// Enrich for the ReviewStateProvider providers.
+	IsPullRequest bool
+	Draft         bool
+	Merged        bool
+	ReviewState   string
+	// Body is the Markdown description of the Issue. TasksTotal and
+	// TasksCompleted count the items of its task lists.
+	Body           string
+	TasksTotal     int
+	TasksCompleted int
+	// Comments, Events and LinkedPullRequests are only set by
+	// IssuesToMarkdown.Enrich
+	Comments           []Comment
+	Events             []Event
+	LinkedPullRequests []LinkedPullRequest
+	// CommentsCount is set by the providers even if the Comments aren't
+	CommentsCount int
+	Reactions     Reactions
+	// Type is the issue type, like Bug or Feature, if the provider has issue
+	// types
+	Type string
+	// Parent is nil when the Issue isn't a sub-issue or the provider doesn't
+	// know its parent. SubIssues are the references of its children.
+	Parent    *IssueReference
+	SubIssues []IssueReference
+}
+
+// IssueReference represents an Issue related to another, which can be in
+// another repository
+type IssueReference struct {
+	Number       int
+	Title        string
+	State        string
+	HTMLURL      string
+	Organization string
+	Repository   string
+}
+
+// Reactions are the reaction counts of an Issue. The providers with votes
+// count them as PlusOne.
+type Reactions struct {
+	TotalCount int
+	PlusOne    int
+	MinusOne   int
+	Laugh      int
+	Hooray     int
+	Confused   int
+	Heart      int
+	Rocket     int
+	Eyes       int
+}
+
+// Comment represents a comment of an Issue
+type Comment struct {
+	Author    User
+	Body      string
+	HTMLURL   string
+	CreatedAt time.Time
+}
+
+// Event represents a timeline event of an Issue, like labeled, closed or
+// referenced
+type Event struct {
+	// Type is the event name of the provider, like the Github timeline
+	// events
+	Type      string
+	Actor     User
+	CreatedAt time.Time
+	// Label is the label of the labeled and unlabeled events
+	Label string
+	// CommitID is the commit of the referenced and closed events
+	CommitID string
+	// SourceURL is the HTML URL of the Issue that referenced this one in the
+	// cross-referenced events
+	SourceURL string
+}
+
+// LinkedPullRequest represents a pull request that references an Issue
+type LinkedPullRequest struct {
+	Number       int
+	Title        string
+	State        string
+	HTMLURL      string
+	Organization string
+	Repository   string
+	Draft        bool
+	Merged       bool
+	// Closes is whether the pull request closes the Issue when merged,
+	// instead of only referencing it
+	Closes bool
+}
+
+// Review states of a pull request
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewRequired         = "review_required"
+)
+
+// Label represents a label of an Issue
+type Label struct {
+	Name string
+	// Color is the hexadecimal RGB color, without the leading #, if the
+	// provider has label colors
+	Color string
+}
+
+// Milestone represents the milestone of an Issue
+type Milestone struct {
+	Title string
+	// DueOn is nil when the milestone has no due date
+	DueOn *time.Time
 }
 
 // NewIssue creates an Issue instance with sensible defaults
@@ -39,16 +183,147 @@ func NewIssue() *Issue {
 
 // GetOrganization return the organization name for this Issue
 func (i *Issue) GetOrganization() (string, error) {
-	parsedU, _ := url.Parse(i.URL)
-	parsedPartsPathU := strings.Split(parsedU.Path, "/")
-	organization := parsedPartsPathU[2]
-	return organization, nil
+	if i.Organization != "" {
+		return i.Organization, nil
+	}
+	organization, _, err := i.parseURL()
+	return organization, err
 }
 
 // GetRepository return the repository name for this Issue
 func (i *Issue) GetRepository() (string, error) {
-	parsedU, _ := url.Parse(i.URL)
+	if i.Repository != "" {
+		return i.Repository, nil
+	}
+	_, repository, err := i.parseURL()
+	return repository, err
+}
+
+// parseURL returns the organization and repository names from the Github API
+// URL of this Issue. The URL path is /repos/{organization}/{repository}/...
+// after any base path, like the /api/v3 of Github Enterprise Server.
+func (i *Issue) parseURL() (string, string, error) {
+	parsedU, err := url.Parse(i.URL)
+	if err != nil {
+		return "", "", err
+	}
 	parsedPartsPathU := strings.Split(parsedU.Path, "/")
-	repository := parsedPartsPathU[3]
-	return repository, nil
+	for idx, part := range parsedPartsPathU {
+		if part == "repos" && idx+2 < len(parsedPartsPathU) {
+			return parsedPartsPathU[idx+1], parsedPartsPathU[idx+2], nil
+		}
+	}
+	return "", "", fmt.Errorf("no organization and repository in issue URL %q", i.URL)
+}
+
+// countTasks returns the number of task list items of a Markdown body and how
+// many are checked. The items inside fenced code blocks aren't tasks.
+func countTasks(body string) (int, int) {
+	total, completed := 0, 0
+	fence := ""
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		match := taskRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		total++
+		if match[1] != " " {
+			completed++
+		}
+	}
+	return total, completed
+}
+
+// closesIssue returns whether a pull request body of the organization and
+// repository has a closing keyword for the issue
+func closesIssue(body string, organization string, repository string, issue *Issue) bool {
+	issueOrganization, issueRepository, err := issueRepository(issue)
+	if err != nil {
+		return false
+	}
+	for _, match := range closingRe.FindAllStringSubmatch(body, -1) {
+		reference := strings.TrimRight(match[1], ".,;:)")
+		if u, err := url.Parse(reference); err == nil && u.Host != "" {
+			// https://github.com/ORG/REPO/issues/N
+			parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+			if len(parts) != 4 || parts[2] != "issues" {
+				continue
+			}
+			reference = parts[0] + "/" + parts[1] + "#" + parts[3]
+		}
+		idx := strings.Index(reference, "#")
+		if idx == -1 || reference[idx+1:] != strconv.Itoa(issue.Number) {
+			continue
+		}
+		referenceOrganization, referenceRepository := organization, repository
+		if idx > 0 {
+			parts := strings.SplitN(reference[:idx], "/", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			referenceOrganization, referenceRepository = parts[0], parts[1]
+		}
+		if strings.EqualFold(referenceOrganization, issueOrganization) && strings.EqualFold(referenceRepository, issueRepository) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeLinkedPullRequests returns the pull requests without duplicates, the
+// pull requests that close the Issue in any reference close it
+func mergeLinkedPullRequests(pullRequests []LinkedPullRequest) []LinkedPullRequest {
+	var result []LinkedPullRequest
+	seen := map[string]int{}
+	for _, pr := range pullRequests {
+		key := issueKey(pr.Organization, pr.Repository, pr.Number)
+		if idx, ok := seen[key]; ok {
+			result[idx].Closes = result[idx].Closes || pr.Closes
+			continue
+		}
+		seen[key] = len(result)
+		result = append(result, pr)
+	}
+	return result
+}
+
+// newIssueReference creates the reference of an Issue
+func newIssueReference(issue *Issue) *IssueReference {
+	reference := &IssueReference{
+		Number:  issue.Number,
+		Title:   issue.Title,
+		State:   issue.State,
+		HTMLURL: issue.HTMLURL,
+	}
+	reference.Organization, reference.Repository, _ = issueRepository(issue)
+	return reference
+}
+
+// issueKey returns the key that identifies an Issue across repositories
+func issueKey(organization string, repository string, number int) string {
+	return strings.ToLower(organization + "/" + repository + "#" + strconv.Itoa(number))
+}
+
+// issueRepository returns the organization and repository names of an Issue
+func issueRepository(issue *Issue) (string, string, error) {
+	organization, err := issue.GetOrganization()
+	if err != nil {
+		return "", "", err
+	}
+	repository, err := issue.GetRepository()
+	if err != nil {
+		return "", "", err
+	}
+	return organization, repository, nil
 }
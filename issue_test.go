@@ -18,7 +18,9 @@
 package issues2markdown_test
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/issues2markdown/issues2markdown"
 )
@@ -55,3 +57,92 @@ func TestGetRepositorynIssue(t *testing.T) {
 		t.Fatalf("Expected organization %q but got %q", expectedOrganization, organization)
 	}
 }
+
+func TestGetOrganizationIssueWithoutURL(t *testing.T) {
+	issue := issues2markdown.NewIssue()
+	_, err := issue.GetOrganization()
+	if err == nil {
+		t.Fatalf("Expected an error for an issue without URL")
+	}
+	issue.Organization = "octocat"
+	organization, err := issue.GetOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if organization != "octocat" {
+		t.Fatalf("Expected organization %q but got %q", "octocat", organization)
+	}
+}
+
+func TestGetOrganizationRepositoryIssueURLs(t *testing.T) {
+	tests := []struct {
+		url          string
+		organization string
+		repository   string
+	}{
+		{"https://api.github.com/repos/octocat/Hello-World/issues/1347", "octocat", "Hello-World"},
+		{"https://github.example.com/api/v3/repos/octocat/Hello-World/issues/1347", "octocat", "Hello-World"},
+		{"https://example.com/github/api/v3/repos/octocat/Hello-World/issues/1347", "octocat", "Hello-World"},
+	}
+	for _, test := range tests {
+		issue := issues2markdown.NewIssue()
+		issue.URL = test.url
+		organization, err := issue.GetOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if organization != test.organization {
+			t.Fatalf("Expected organization %q for %s but got %q", test.organization, test.url, organization)
+		}
+		repository, err := issue.GetRepository()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repository != test.repository {
+			t.Fatalf("Expected repository %q for %s but got %q", test.repository, test.url, repository)
+		}
+	}
+}
+
+// testIssueFields checks the author, assignees, labels and milestone of an
+// issue
+func testIssueFields(t *testing.T, got, expected issues2markdown.Issue) {
+	t.Helper()
+	if got.Author != expected.Author {
+		t.Errorf("Expected author %+v but got %+v", expected.Author, got.Author)
+	}
+	if !reflect.DeepEqual(got.Assignees, expected.Assignees) {
+		t.Errorf("Expected assignees %+v but got %+v", expected.Assignees, got.Assignees)
+	}
+	if !reflect.DeepEqual(got.Labels, expected.Labels) {
+		t.Errorf("Expected labels %+v but got %+v", expected.Labels, got.Labels)
+	}
+	if !reflect.DeepEqual(got.Milestone, expected.Milestone) {
+		t.Errorf("Expected milestone %+v but got %+v", expected.Milestone, got.Milestone)
+	}
+}
+
+// date returns the time of a date in UTC
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+// testIssueTimes checks the creation, update and close times of an issue,
+// formatted as RFC 3339 in UTC. An empty close time expects no close time.
+func testIssueTimes(t *testing.T, got issues2markdown.Issue, createdAt, updatedAt, closedAt string) {
+	t.Helper()
+	if s := got.CreatedAt.UTC().Format(time.RFC3339Nano); s != createdAt {
+		t.Errorf("Expected created at %s but got %s", createdAt, s)
+	}
+	if s := got.UpdatedAt.UTC().Format(time.RFC3339Nano); s != updatedAt {
+		t.Errorf("Expected updated at %s but got %s", updatedAt, s)
+	}
+	s := ""
+	if got.ClosedAt != nil {
+		s = got.ClosedAt.UTC().Format(time.RFC3339Nano)
+	}
+	if s != closedAt {
+		t.Errorf("Expected closed at %q but got %q", closedAt, s)
+	}
+}
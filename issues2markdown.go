@@ -20,24 +20,23 @@ package issues2markdown
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"html/template"
 	"strings"
-
-	"github.com/google/go-github/github"
 )
 
 // IssuesToMarkdown is the main type to interact, query and render issues to
 // Markdown
 type IssuesToMarkdown struct {
 	GithubToken string
-	client      *github.Client
-	User        *github.User
+	provider    IssueProvider
+	User        *User
 }
 
 // NewIssuesToMarkdown creates an IssuesToMarkdown instance
-func NewIssuesToMarkdown(provider *github.Client) (*IssuesToMarkdown, error) {
+func NewIssuesToMarkdown(provider IssueProvider) (*IssuesToMarkdown, error) {
 	i2md := &IssuesToMarkdown{
-		client: provider,
+		provider: provider,
 	}
 	user, err := i2md.Authorize()
 	if err != nil {
@@ -48,10 +47,10 @@ func NewIssuesToMarkdown(provider *github.Client) (*IssuesToMarkdown, error) {
 }
 
 // Authorize gets authentication information
-func (im *IssuesToMarkdown) Authorize() (*github.User, error) {
+func (im *IssuesToMarkdown) Authorize() (*User, error) {
 	ctx := context.Background()
 	// get user information
-	user, _, err := im.client.Users.Get(ctx, "")
+	user, err := im.provider.Authorize(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -59,48 +58,92 @@ func (im *IssuesToMarkdown) Authorize() (*github.User, error) {
 }
 
 // Query queries the provider and returns the list of Issues that match
-// the query
+// the query. The query is validated with ParseSearchQuery, or the
+// QueryValidator of the provider, before querying the provider. If the
+// provider can't return all the results, the Issues retrieved are returned
+// with an IncompleteResultsError.
 func (im *IssuesToMarkdown) Query(options *QueryOptions, q string) ([]Issue, error) {
 	ctx := context.Background()
 
-	// query issues
-	var result []Issue
-	query := options.BuildQuey(q)
+	// the default query selects the items of the authorized user, the
+	// query templates have its login and teams
+	defaults := *options
+	options = &defaults
+	if im.User != nil {
+		if options.Organization == "" {
+			options.Organization = im.User.Login
+		}
+		options.User = im.User
+	}
+	if teamsProvider, ok := im.provider.(TeamsProvider); ok {
+		options.teams = func() ([]string, error) {
+			return teamsProvider.Teams(ctx)
+		}
+	}
 
-	githubOptions := &github.SearchOptions{}
-	for {
-		listResult, response, err := im.client.Search.Issues(ctx, query, githubOptions)
-		if err != nil {
-			return nil, err
+	// query issues, the named queries are replaced by their templates
+	var name string
+	if options.Config != nil {
+		if _, ok := options.Config.Queries[q]; ok {
+			name = q
+			q = options.Config.Queries[q].Query
+		}
+	}
+	query, executed, err := options.buildQuery(q)
+	if err != nil {
+		if name != "" {
+			return nil, fmt.Errorf("query %q: %v", name, err)
 		}
+		return nil, err
+	}
+	// the query provided is validated, so the errors are at its positions
+	if err := validateQuery(im.provider, executed); err != nil {
+		return nil, err
+	}
+	searchOptions := &SearchOptions{
+		IncludeBody: options.IncludeBody,
+		Hierarchy:   options.ExpandHierarchy,
+	}
+	// the incomplete results are returned with the error
+	result, searchErr := searchAll(ctx, im.provider, query, searchOptions)
+	if _, ok := searchErr.(*IncompleteResultsError); searchErr != nil && !ok {
+		return nil, searchErr
+	}
 
-		// process page results
-		for _, v := range listResult.Issues {
-			item := Issue{
-				Number:  *v.Number,
-				Title:   *v.Title,
-				State:   *v.State,
-				URL:     *v.URL,
-				HTMLURL: *v.HTMLURL,
-			}
-			result = append(result, item)
+	// add the sub-issues
+	if options.ExpandHierarchy {
+		hierarchyProvider, ok := im.provider.(HierarchyProvider)
+		if !ok {
+			return nil, fmt.Errorf("sub-issues aren't supported by the provider")
+		}
+		var err error
+		result, err = expandHierarchy(ctx, hierarchyProvider, result, searchOptions)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		// process pagination
-		if response.NextPage == 0 {
-			break
+	// count the tasks of the bodies
+	if options.IncludeBody {
+		for i := range result {
+			result[i].TasksTotal, result[i].TasksCompleted = countTasks(result[i].Body)
 		}
-		githubOptions.Page = response.NextPage
 	}
 
-	return result, nil
+	return result, searchErr
 }
 
-// Render renders a list of Issues to Markdown
+// Render renders a list of Issues to Markdown. The errors of the template,
+// like the invalid arguments of its functions, are returned.
 func (im *IssuesToMarkdown) Render(issues []Issue, options *RenderOptions) (string, error) {
 	var compiled bytes.Buffer
-	t := template.Must(template.New("issueslist").Parse(options.TemplateSource))
-	_ = t.Execute(&compiled, issues)
+	t, err := template.New("issueslist").Funcs(options.funcs()).Parse(options.TemplateSource)
+	if err != nil {
+		return "", err
+	}
+	if err := t.Execute(&compiled, issues); err != nil {
+		return "", err
+	}
 	result := compiled.String()
 	result = strings.TrimRight(result, "\n") // trim the last linebreak
 	return result, nil
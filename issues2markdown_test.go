@@ -20,13 +20,17 @@ package issues2markdown_test
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/github"
 	"github.com/issues2markdown/issues2markdown"
+	"github.com/issues2markdown/issues2markdown/replay"
 	"golang.org/x/oauth2"
 )
 
@@ -79,7 +83,7 @@ func TestInstanceIssuesToMarkdown(t *testing.T) {
 	})
 	defer teardown()
 
-	_, err := issues2markdown.NewIssuesToMarkdown(issuesProvider)
+	_, err := issues2markdown.NewIssuesToMarkdown(issues2markdown.NewGithubProvider(issuesProvider))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -94,7 +98,7 @@ func TestInstanceIssuesToMarkdownUnauthorized(t *testing.T) {
 	})
 	defer teardown()
 
-	_, err := issues2markdown.NewIssuesToMarkdown(issuesProvider)
+	_, err := issues2markdown.NewIssuesToMarkdown(issues2markdown.NewGithubProvider(issuesProvider))
 	if err == nil {
 		t.Fatal(err)
 	}
@@ -114,7 +118,7 @@ func TestQuery(t *testing.T) {
 	})
 	defer teardown()
 
-	i2md, err := issues2markdown.NewIssuesToMarkdown(issuesProvider)
+	i2md, err := issues2markdown.NewIssuesToMarkdown(issues2markdown.NewGithubProvider(issuesProvider))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -152,7 +156,7 @@ func TestRender(t *testing.T) {
 	}
 	defer teardown()
 
-	i2md, err := issues2markdown.NewIssuesToMarkdown(issuesProvider)
+	i2md, err := issues2markdown.NewIssuesToMarkdown(issues2markdown.NewGithubProvider(issuesProvider))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -170,3 +174,212 @@ func TestRender(t *testing.T) {
 		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
 	}
 }
+
+func TestRenderIssueFields(t *testing.T) {
+	dueOn := time.Date(2018, 10, 9, 0, 0, 0, 0, time.UTC)
+	issues := []issues2markdown.Issue{
+		{
+			Number:    1,
+			Title:     "Issue title 1",
+			State:     "open",
+			HTMLURL:   "https://github.com/username/repo/issues/1",
+			Author:    issues2markdown.User{Login: "username"},
+			Assignees: []issues2markdown.User{{Login: "octocat"}, {Login: "hubot"}},
+			Labels:    []issues2markdown.Label{{Name: "bug", Color: "d73a4a"}},
+			Milestone: &issues2markdown.Milestone{Title: "v1.0", DueOn: &dueOn},
+		},
+		{
+			Number:  2,
+			Title:   "Issue title 2",
+			State:   "open",
+			HTMLURL: "https://github.com/username/repo/issues/2",
+			Author:  issues2markdown.User{Login: "username"},
+		},
+	}
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(&fakeProvider{user: &issues2markdown.User{Login: "username"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewRenderOptions()
+	options.TemplateSource = `{{ range . }}- #{{ .Number }} by {{ .Author.Login }}` +
+		`{{ range .Assignees }} @{{ .Login }}{{ end }}` +
+		`{{ range .Labels }} [{{ .Name }}:{{ .Color }}]{{ end }}` +
+		`{{ with .Milestone }} {{ .Title }} due {{ .DueOn.Format "2006-01-02" }}{{ end }}
+{{ end }}`
+	markdown, err := i2md.Render(issues, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMarkdown := `- #1 by username @octocat @hubot [bug:d73a4a] v1.0 due 2018-10-09
+- #2 by username`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+// fakeProvider is an IssueProvider test double that serves a fixed list of
+// Issues split in pages of one Issue
+type fakeProvider struct {
+	user    *issues2markdown.User
+	issues  []issues2markdown.Issue
+	queries []string
+	options []issues2markdown.SearchOptions
+}
+
+func (fp *fakeProvider) Authorize(ctx context.Context) (*issues2markdown.User, error) {
+	if fp.user == nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	return fp.user, nil
+}
+
+func (fp *fakeProvider) Search(ctx context.Context, query string, options *issues2markdown.SearchOptions) (*issues2markdown.SearchResult, error) {
+	fp.queries = append(fp.queries, query)
+	fp.options = append(fp.options, *options)
+	page := 0
+	if options.Page != "" {
+		_, _ = fmt.Sscan(options.Page, &page)
+	}
+	result := &issues2markdown.SearchResult{}
+	if page < len(fp.issues) {
+		result.Issues = fp.issues[page : page+1]
+	}
+	if page+1 < len(fp.issues) {
+		result.NextPage = fmt.Sprint(page + 1)
+	}
+	return result, nil
+}
+
+func TestQueryCustomProvider(t *testing.T) {
+	provider := &fakeProvider{
+		user: &issues2markdown.User{Login: "username"},
+		issues: []issues2markdown.Issue{
+			{Number: 1, Title: "Issue title 1", State: "open"},
+			{Number: 2, Title: "Issue title 2", State: "closed"},
+		},
+	}
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i2md.User.Login != "username" {
+		t.Fatalf("Expected user %q but got %q", "username", i2md.User.Login)
+	}
+
+	options := issues2markdown.NewQueryOptions()
+	options.Organization = "username"
+	issues, err := i2md.Query(options, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("Expected %d issues but got %d", 2, len(issues))
+	}
+	if len(provider.queries) != 2 {
+		t.Fatalf("Expected %d searches but got %d", 2, len(provider.queries))
+	}
+	expectedQuery := "type:issue is:open author:username archived:false"
+	if provider.queries[0] != expectedQuery {
+		t.Fatalf("Expected query %q but got %q", expectedQuery, provider.queries[0])
+	}
+}
+
+func TestQueryIncludeBody(t *testing.T) {
+	body := "Epic\r\n\n- [x] first\n- [ ] second\n  * [X] nested\n1. [ ] numbered\n- [] not a task\n- [x]not a task\n" +
+		"```\n- [ ] code\n```\n~~~md\n- [x] code\n~~~\n+ [x] last"
+	provider := &fakeProvider{
+		user: &issues2markdown.User{Login: "username"},
+		issues: []issues2markdown.Issue{
+			{Number: 1, Title: "Issue title 1", Body: body},
+			{Number: 2, Title: "Issue title 2", Body: "No tasks"},
+		},
+	}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := issues2markdown.NewQueryOptions()
+	issues, err := i2md.Query(options, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues[0].TasksTotal != 0 || provider.options[0].IncludeBody {
+		t.Fatalf("Expected no tasks without bodies but got %d", issues[0].TasksTotal)
+	}
+
+	options.IncludeBody = true
+	issues, err = i2md.Query(options, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, searchOptions := range provider.options[2:] {
+		if !searchOptions.IncludeBody {
+			t.Fatal("Expected every search to include the bodies")
+		}
+	}
+	if issues[0].TasksTotal != 5 || issues[0].TasksCompleted != 3 {
+		t.Fatalf("Expected %d of %d tasks completed but got %d of %d", 3, 5, issues[0].TasksCompleted, issues[0].TasksTotal)
+	}
+	if issues[1].TasksTotal != 0 || issues[1].TasksCompleted != 0 {
+		t.Fatalf("Expected no tasks but got %d of %d", issues[1].TasksCompleted, issues[1].TasksTotal)
+	}
+
+	render := issues2markdown.NewRenderOptions()
+	render.TemplateSource = `{{ range . }}{{ .Title }}{{ if .TasksTotal }} ({{ .TasksCompleted }}/{{ .TasksTotal }} done){{ end }}
+{{ end }}`
+	markdown, err := i2md.Render(issues, render)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expectedMarkdown := "Issue title 1 (3/5 done)\nIssue title 2"; markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestInstanceIssuesToMarkdownCustomProviderUnauthorized(t *testing.T) {
+	_, err := issues2markdown.NewIssuesToMarkdown(&fakeProvider{})
+	if err == nil {
+		t.Fatal("Expected an authorization error")
+	}
+}
+
+func TestQueryRenderReplay(t *testing.T) {
+	recorder, err := replay.New("testdata/github_query.cassette.json", replay.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := recorder.Stop(); err != nil {
+			t.Error(err)
+		}
+	}()
+	provider := issues2markdown.NewGithubProvider(github.NewClient(recorder.Client()))
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	options.Organization = i2md.User.Login
+	issues, err := i2md.Query(options, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := i2md.Render(issues, issues2markdown.NewRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden, err := ioutil.ReadFile("testdata/github_query.golden.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMarkdown := strings.TrimRight(string(golden), "\n")
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
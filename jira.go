@@ -0,0 +1,444 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// jiraPageSize is the number of issues requested per page
+	jiraPageSize = 50
+)
+
+// jqlRe matches the operators and keywords of a JQL query
+var jqlRe = regexp.MustCompile(`!=|!~|[=~<>]|(?i:\border\s+by\b)|\b(AND|OR|NOT|IN|IS|WAS|EMPTY)\b`)
+
+// jqlOrderByRe matches the ORDER BY clause of a JQL query
+var jqlOrderByRe = regexp.MustCompile(`(?i)\border\s+by\b`)
+
+// qualifierRe matches a search query qualifier
+var qualifierRe = regexp.MustCompile(`^-?[a-zA-Z]+:[^:]`)
+
+// JiraProvider is the IssueProvider for the Jira Cloud and Jira Server REST
+// API
+type JiraProvider struct {
+	client   *http.Client
+	BaseURL  *url.URL
+	Username string
+	Token    string
+}
+
+// NewJiraProvider creates a JiraProvider instance for the Jira site at
+// baseURL.
+//
+// Jira Cloud authenticates with the account email as username and an API
+// token. Jira Server authenticates with a personal access token and an empty
+// username. If the provided httpClient is nil http.DefaultClient is used.
+func NewJiraProvider(httpClient *http.Client, baseURL string, username string, token string) (*JiraProvider, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("jira: a base URL is required")
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	parsedBaseURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	provider := &JiraProvider{
+		client:   httpClient,
+		BaseURL:  parsedBaseURL,
+		Username: username,
+		Token:    token,
+	}
+	return provider, nil
+}
+
+// jiraUser represents a Jira user
+type jiraUser struct {
+	Name         string `json:"name"`
+	AccountID    string `json:"accountId"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+// login returns the user name or, as Jira Cloud doesn't expose user names,
+// the email address or the account ID
+func (u *jiraUser) login() string {
+	if u.Name != "" {
+		return u.Name
+	}
+	if u.EmailAddress != "" {
+		return u.EmailAddress
+	}
+	return u.AccountID
+}
+
+// jiraSearchResult represents a page of the Jira search API
+type jiraSearchResult struct {
+	StartAt int         `json:"startAt"`
+	Total   int         `json:"total"`
+	Issues  []jiraIssue `json:"issues"`
+}
+
+// jiraIssue represents a Jira issue
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Self   string `json:"self"`
+	Fields struct {
+		Summary string `json:"summary"`
+		Status  struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Reporter    *jiraUser `json:"reporter"`
+		Assignee    *jiraUser `json:"assignee"`
+		Labels      []string  `json:"labels"`
+		FixVersions []struct {
+			Name        string `json:"name"`
+			ReleaseDate string `json:"releaseDate"`
+		} `json:"fixVersions"`
+		Description string `json:"description"`
+		Votes       struct {
+			Votes int `json:"votes"`
+		} `json:"votes"`
+		Created        string `json:"created"`
+		Updated        string `json:"updated"`
+		ResolutionDate string `json:"resolutiondate"`
+	} `json:"fields"`
+}
+
+// Authorize gets authentication information
+func (jp *JiraProvider) Authorize(ctx context.Context) (*User, error) {
+	req, err := jp.newRequest("rest/api/2/myself")
+	if err != nil {
+		return nil, err
+	}
+	user := &jiraUser{}
+	_, err = doJSON(ctx, jp.client, req, user)
+	if err != nil {
+		return nil, err
+	}
+	result := &User{
+		Login: user.login(),
+	}
+	return result, nil
+}
+
+// Search returns a page of the Issues that match the query
+//
+// The query can be raw JQL, Github style qualifiers or both. The qualifiers
+// is:, state:, author:, assignee:, label:, milestone: and repo:, that selects
+// a project, are translated to JQL clauses. The rest of the query is used as
+// a JQL query if it contains any JQL operator or as a text search otherwise.
+func (jp *JiraProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	jql, err := buildJQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	startAt := 0
+	if options.Page != "" {
+		startAt, err = strconv.Atoi(options.Page)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := jp.newRequest("rest/api/2/search")
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("jql", jql)
+	params.Set("startAt", strconv.Itoa(startAt))
+	params.Set("maxResults", strconv.Itoa(jiraPageSize))
+	fields := "summary,status,project,reporter,assignee,labels,fixVersions,created,updated,resolutiondate,votes"
+	if options.IncludeBody {
+		fields += ",description"
+	}
+	params.Set("fields", fields)
+	req.URL.RawQuery = params.Encode()
+
+	searchResult := &jiraSearchResult{}
+	_, err = doJSON(ctx, jp.client, req, searchResult)
+	if err != nil {
+		return nil, err
+	}
+
+	// process page results
+	result := &SearchResult{}
+	for _, v := range searchResult.Issues {
+		result.Issues = append(result.Issues, jp.newIssueFromJira(&v))
+	}
+
+	// process pagination
+	next := searchResult.StartAt + len(searchResult.Issues)
+	if len(searchResult.Issues) > 0 && next < searchResult.Total {
+		result.NextPage = strconv.Itoa(next)
+	}
+	return result, nil
+}
+
+// newRequest creates an authenticated GET request for the API path,
+// relative to BaseURL
+func (jp *JiraProvider) newRequest(path string) (*http.Request, error) {
+	u, err := jp.BaseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if jp.Username != "" {
+		req.SetBasicAuth(jp.Username, jp.Token)
+	} else if jp.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+jp.Token)
+	}
+	return req, nil
+}
+
+// ValidateQuery validates the Github style qualifiers of the query, and the
+// rest of the query if it isn't JQL
+func (jp *JiraProvider) ValidateQuery(query string) error {
+	qualifiers, rest := splitJQLQuery(query)
+	if jqlRe.MatchString(rest) {
+		query = qualifiers
+	}
+	_, err := ParseSearchQuery(query)
+	return err
+}
+
+// splitJQLQuery splits the Github style qualifiers of a query, with the
+// operators and parentheses that combine them, from the rest of the query,
+// which is either JQL or a text search
+func splitJQLQuery(query string) (string, string) {
+	p := &searchQueryParser{query: query, lenient: true}
+	tokens := p.tokenize(query)
+
+	// the operators and parentheses next to the qualifiers are theirs
+	marked := make([]bool, len(tokens))
+	for i, token := range tokens {
+		marked[i] = qualifierRe.MatchString(token.value)
+	}
+	for changed := true; changed; {
+		changed = false
+		for i, token := range tokens {
+			if marked[i] {
+				continue
+			}
+			switch token.value {
+			case "OR", "NOT", "(":
+				marked[i] = i+1 < len(tokens) && marked[i+1]
+			case ")":
+				marked[i] = i > 0 && marked[i-1]
+			}
+			changed = changed || marked[i]
+		}
+	}
+
+	// the rest keeps the spacing of the query between its tokens
+	var qualifiers []string
+	var rest strings.Builder
+	last := -1
+	for i, token := range tokens {
+		if marked[i] {
+			qualifiers = append(qualifiers, token.value)
+			continue
+		}
+		if last != -1 {
+			previous := tokens[last]
+			if last == i-1 {
+				_, _ = rest.WriteString(query[previous.offset+len(previous.value) : token.offset])
+			} else {
+				_, _ = rest.WriteString(" ")
+			}
+		}
+		_, _ = rest.WriteString(token.value)
+		last = i
+	}
+	return strings.Join(qualifiers, " "), rest.String()
+}
+
+// buildJQL translates a search query to JQL
+func buildJQL(query string) (string, error) {
+	qualifiers, raw := splitJQLQuery(query)
+	terms := parseSearchTerms(qualifiers)
+
+	var clauses []string
+	switch terms.state() {
+	case "open":
+		clauses = append(clauses, "statusCategory != Done")
+	case "closed":
+		clauses = append(clauses, "statusCategory = Done")
+	}
+	terms.remove("is", "open", "closed", "issue")
+	terms.remove("state", "open", "closed")
+
+	if projects := terms.qualifiers["repo"]; len(projects) > 0 {
+		for i, project := range projects {
+			// repo:organization/repository selects the repository project
+			projects[i] = jqlString(project[strings.LastIndex(project, "/")+1:])
+		}
+		clauses = append(clauses, fmt.Sprintf("project in (%s)", strings.Join(projects, ", ")))
+	}
+	terms.remove("repo")
+
+	fields := map[string]string{
+		"author":    "reporter",
+		"assignee":  "assignee",
+		"label":     "labels",
+		"milestone": "fixVersion",
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := fields[key]
+		for _, v := range terms.qualifiers[key] {
+			clauses = append(clauses, fmt.Sprintf("%s = %s", field, jqlValue(v)))
+		}
+		for _, v := range terms.qualifiers["-"+key] {
+			clauses = append(clauses, fmt.Sprintf("%s != %s", field, jqlValue(v)))
+		}
+		terms.remove(key)
+		terms.remove("-" + key)
+	}
+
+	terms.remove("type", "issue")
+	terms.remove("archived", "false")
+	if err := terms.unsupported("jira"); err != nil {
+		return "", err
+	}
+
+	// the rest of the query is either JQL or a text search
+	orderBy := ""
+	if jqlRe.MatchString(raw) {
+		if loc := jqlOrderByRe.FindStringIndex(raw); loc != nil {
+			orderBy = raw[loc[0]:]
+			raw = strings.TrimSpace(raw[:loc[0]])
+		}
+		if raw != "" {
+			clauses = append(clauses, "("+raw+")")
+		}
+	} else if raw != "" {
+		clauses = append(clauses, fmt.Sprintf("text ~ %s", jqlString(strings.Trim(raw, `"`))))
+	}
+
+	jql := strings.Join(clauses, " AND ")
+	if orderBy != "" {
+		jql = strings.TrimSpace(jql + " " + orderBy)
+	}
+	return jql, nil
+}
+
+// jqlValue returns the JQL value for a user or field value, @me being the
+// current user
+func jqlValue(v string) string {
+	if v == "@me" {
+		return "currentUser()"
+	}
+	return jqlString(v)
+}
+
+// jqlString returns a quoted JQL string
+func jqlString(v string) string {
+	return strconv.Quote(v)
+}
+
+// newIssueFromJira creates an Issue from a Jira issue
+//
+// The Jira site is used as the organization and the project as the
+// repository. Issues in the done status category are closed.
+func (jp *JiraProvider) newIssueFromJira(v *jiraIssue) Issue {
+	item := Issue{
+		Title:        v.Fields.Summary,
+		State:        "open",
+		URL:          v.Self,
+		Organization: jp.BaseURL.Host,
+		Repository:   v.Fields.Project.Key,
+	}
+	if idx := strings.LastIndex(v.Key, "-"); idx >= 0 {
+		item.Number, _ = strconv.Atoi(v.Key[idx+1:])
+	}
+	if v.Fields.Status.StatusCategory.Key == "done" {
+		item.State = "closed"
+	}
+	if u, err := jp.BaseURL.Parse("browse/" + v.Key); err == nil {
+		item.HTMLURL = u.String()
+	}
+	item.Body = v.Fields.Description
+	item.Reactions = Reactions{
+		TotalCount: v.Fields.Votes.Votes,
+		PlusOne:    v.Fields.Votes.Votes,
+	}
+	if t := parseJiraTime(v.Fields.Created); t != nil {
+		item.CreatedAt = *t
+	}
+	if t := parseJiraTime(v.Fields.Updated); t != nil {
+		item.UpdatedAt = *t
+	}
+	item.ClosedAt = parseJiraTime(v.Fields.ResolutionDate)
+	if v.Fields.Reporter != nil {
+		item.Author.Login = v.Fields.Reporter.login()
+	}
+	if v.Fields.Assignee != nil {
+		item.Assignees = []User{{Login: v.Fields.Assignee.login()}}
+	}
+	for _, label := range v.Fields.Labels {
+		item.Labels = append(item.Labels, Label{Name: label})
+	}
+	// the milestone: qualifier selects fix versions, so the first one is the
+	// milestone
+	if len(v.Fields.FixVersions) > 0 {
+		item.Milestone = &Milestone{
+			Title: v.Fields.FixVersions[0].Name,
+			DueOn: parseDate(v.Fields.FixVersions[0].ReleaseDate),
+		}
+	}
+	return item
+}
+
+// parseJiraTime parses the Jira timestamps, which time zone offset has no
+// colon. It returns nil for empty or invalid timestamps.
+func parseJiraTime(value string) *time.Time {
+	t, err := time.Parse("2006-01-02T15:04:05.000-0700", value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
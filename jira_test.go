@@ -0,0 +1,279 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// jiraSetup sets up a test HTTP server along with a JiraProvider that is
+// configured to talk to that test server.
+func jiraSetup(t *testing.T) (provider *issues2markdown.JiraProvider, mux *http.ServeMux, serverURL string, teardown func()) {
+	mux = http.NewServeMux()
+	server := httptest.NewServer(mux)
+	provider, err := issues2markdown.NewJiraProvider(nil, server.URL, "user@example.com", "jira_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, mux, server.URL, server.Close
+}
+
+func TestJiraProviderAuthorize(t *testing.T) {
+	provider, mux, _, teardown := jiraSetup(t)
+	mux.HandleFunc("/rest/api/2/myself", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		username, token, ok := r.BasicAuth()
+		if !ok || username != "user@example.com" || token != "jira_token" {
+			t.Errorf("Expected basic authentication but got %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"accountId": "5b10a2844c20165700ede21g", "emailAddress": "user@example.com"}`)
+	})
+	defer teardown()
+
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "user@example.com" {
+		t.Fatalf("Expected login %q but got %q", "user@example.com", user.Login)
+	}
+}
+
+func TestJiraProviderAuthorizePersonalAccessToken(t *testing.T) {
+	provider, mux, _, teardown := jiraSetup(t)
+	provider.Username = ""
+	mux.HandleFunc("/rest/api/2/myself", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer jira_token" {
+			t.Errorf("Expected authorization %q but got %q", "Bearer jira_token", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "username"}`)
+	})
+	defer teardown()
+
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "username" {
+		t.Fatalf("Expected login %q but got %q", "username", user.Login)
+	}
+}
+
+func TestJiraProviderSearchJQL(t *testing.T) {
+	tests := []struct {
+		query string
+		jql   string
+	}{
+		{
+			query: "type:issue is:open author:username archived:false",
+			jql:   `statusCategory != Done AND reporter = "username"`,
+		},
+		{
+			query: "type:issue is:closed repo:organization/PROJ assignee:@me label:backend -label:wontfix",
+			jql:   `statusCategory = Done AND project in ("PROJ") AND assignee = currentUser() AND labels = "backend" AND labels != "wontfix"`,
+		},
+		{
+			query: `type:issue project = PROJ AND status = "In Progress" ORDER BY created DESC`,
+			jql:   `(project = PROJ AND status = "In Progress") ORDER BY created DESC`,
+		},
+		{
+			query: `type:issue is:open fixVersion = 1.0 order by rank`,
+			jql:   `statusCategory != Done AND (fixVersion = 1.0) order by rank`,
+		},
+		{
+			query: `type:issue NOT label:wontfix (status = "In  Progress" OR priority in (High, Highest))`,
+			jql:   `labels != "wontfix" AND ((status = "In  Progress" OR priority in (High, Highest)))`,
+		},
+		{
+			query: "type:issue is:open login page crash",
+			jql:   `statusCategory != Done AND text ~ "login page crash"`,
+		},
+	}
+	for _, test := range tests {
+		provider, mux, _, teardown := jiraSetup(t)
+		mux.HandleFunc("/rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			if got := r.URL.Query().Get("jql"); got != test.jql {
+				t.Errorf("Expected JQL %q for query %q but got %q", test.jql, test.query, got)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"startAt": 0, "total": 0, "issues": []}`)
+		})
+
+		_, err := provider.Search(context.Background(), test.query, &issues2markdown.SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		teardown()
+	}
+}
+
+func TestJiraProviderSearchUnsupportedQualifier(t *testing.T) {
+	provider, _, _, teardown := jiraSetup(t)
+	defer teardown()
+
+	_, err := provider.Search(context.Background(), "type:issue org:organization", &issues2markdown.SearchOptions{})
+	if err == nil {
+		t.Fatal("Expected an unsupported qualifier error")
+	}
+}
+
+func TestJiraProviderSearchUnsupportedOperators(t *testing.T) {
+	provider, _, _, teardown := jiraSetup(t)
+	defer teardown()
+
+	for _, query := range []string{"type:issue label:a OR label:b", "type:issue (label:a OR label:b)", "type:issue is:open (label:a OR label:b) project = PROJ"} {
+		_, err := provider.Search(context.Background(), query, &issues2markdown.SearchOptions{})
+		if err == nil || !strings.Contains(err.Error(), "unsupported search operator") {
+			t.Fatalf("Expected an unsupported operator error for %q but got %v", query, err)
+		}
+	}
+}
+
+func TestJiraProviderQueryRender(t *testing.T) {
+	provider, mux, serverURL, teardown := jiraSetup(t)
+	mux.HandleFunc("/rest/api/2/myself", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "username"}`)
+	})
+	mux.HandleFunc("/rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		issue := `{"key": "PROJ-%d", "self": "%s/rest/api/2/issue/1000%d", "fields": {"summary": "Issue title %d", "status": {"name": "%s", "statusCategory": {"key": "%s"}}, "project": {"key": "PROJ"}}}`
+		switch r.URL.Query().Get("startAt") {
+		case "0":
+			fmt.Fprintf(w, `{"startAt": 0, "total": 2, "issues": [`+issue+`]}`, 1, serverURL, 1, 1, "In Progress", "indeterminate")
+		case "1":
+			fmt.Fprintf(w, `{"startAt": 1, "total": 2, "issues": [`+issue+`]}`, 2, serverURL, 2, 2, "Done", "done")
+		default:
+			t.Errorf("Unexpected startAt %q", r.URL.Query().Get("startAt"))
+		}
+	})
+	defer teardown()
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "project = PROJ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := i2md.Render(issues, issues2markdown.NewRenderOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(serverURL, "http://")
+	expectedMarkdown := fmt.Sprintf(`- [ ] %s/PROJ : [#1 Issue title 1](%s/browse/PROJ-1)
+- [x] %s/PROJ : [#2 Issue title 2](%s/browse/PROJ-2)`, host, serverURL, host, serverURL)
+
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestJiraProviderQueryRawJQL(t *testing.T) {
+	provider, mux, _, teardown := jiraSetup(t)
+	mux.HandleFunc("/rest/api/2/myself", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "username"}`)
+	})
+	var jql []string
+	mux.HandleFunc("/rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+		jql = append(jql, r.URL.Query().Get("jql"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"startAt": 0, "total": 0, "issues": []}`)
+	})
+	defer teardown()
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i2md.Query(issues2markdown.NewQueryOptions(), "is:open updated >= '2024-01-01 00:00'"); err != nil {
+		t.Fatal(err)
+	}
+	expectedJQL := "statusCategory != Done AND (updated >= '2024-01-01 00:00')"
+	if len(jql) != 1 || jql[0] != expectedJQL {
+		t.Fatalf("Expected JQL %q but got %q", expectedJQL, jql)
+	}
+
+	if _, err := i2md.Query(issues2markdown.NewQueryOptions(), "is:maybe updated >= '2024-01-01 00:00'"); err == nil {
+		t.Fatal("Expected an error for an invalid qualifier value")
+	}
+}
+
+func TestJiraProviderSearchIssueFields(t *testing.T) {
+	provider, mux, serverURL, teardown := jiraSetup(t)
+	mux.HandleFunc("/rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"startAt": 0, "total": 1, "issues": [{"key": "PROJ-1", "self": "%s/rest/api/2/issue/10001", "fields": {"summary": "Issue title 1", "status": {"statusCategory": {"key": "new"}}, "project": {"key": "PROJ"},
+			"reporter": {"accountId": "5b10a2844c20165700ede21g"},
+			"assignee": {"name": "octocat"},
+			"labels": ["backend"],
+			"fixVersions": [{"name": "1.0", "releaseDate": "2018-10-09"}, {"name": "1.1"}],
+			"created": "2018-10-01T12:00:00.000+0200", "updated": "2018-10-02T10:00:00.000+0000", "resolutiondate": null}}]}`, serverURL)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 1 {
+		t.Fatalf("Expected %d issues but got %d", 1, len(result.Issues))
+	}
+	testIssueFields(t, result.Issues[0], issues2markdown.Issue{
+		Author:    issues2markdown.User{Login: "5b10a2844c20165700ede21g"},
+		Assignees: []issues2markdown.User{{Login: "octocat"}},
+		Labels:    []issues2markdown.Label{{Name: "backend"}},
+		Milestone: &issues2markdown.Milestone{Title: "1.0", DueOn: date(2018, 10, 9)},
+	})
+	testIssueTimes(t, result.Issues[0], "2018-10-01T10:00:00Z", "2018-10-02T10:00:00Z", "")
+}
+
+func TestJiraProviderSearchIncludeBody(t *testing.T) {
+	provider, mux, _, teardown := jiraSetup(t)
+	mux.HandleFunc("/rest/api/2/search", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if fields := r.URL.Query().Get("fields"); !strings.HasSuffix(fields, ",description") {
+			t.Errorf("Expected the description field but got %q", fields)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"startAt": 0, "total": 1, "issues": [{"key": "PROJ-1", "fields": {"summary": "Issue title 1", "project": {"key": "PROJ"}, "description": "* [x] done"}}]}`)
+	})
+	defer teardown()
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{IncludeBody: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Issues[0].Body != "* [x] done" {
+		t.Fatalf("Expected body %q but got %q", "* [x] done", result.Issues[0].Body)
+	}
+}
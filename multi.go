@@ -0,0 +1,306 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MultiProvider is the IssueProvider that sends the same query to several
+// providers and merges their Issues
+type MultiProvider struct {
+	sources []multiProviderSource
+	// mirrors are the repositories mirrored by the mirror repositories, as
+	// lower case ORGANIZATION/REPOSITORY
+	mirrors map[string]string
+}
+
+// multiProviderSource is a provider of a MultiProvider
+type multiProviderSource struct {
+	name     string
+	provider IssueProvider
+}
+
+// NewMultiProvider creates an empty MultiProvider instance
+func NewMultiProvider() *MultiProvider {
+	provider := &MultiProvider{
+		mirrors: make(map[string]string),
+	}
+	return provider
+}
+
+// Add adds a provider to the MultiProvider. The name is used as the Source
+// of its Issues.
+func (mp *MultiProvider) Add(name string, provider IssueProvider) {
+	mp.sources = append(mp.sources, multiProviderSource{
+		name:     name,
+		provider: provider,
+	})
+}
+
+// AddMirror declares the mirror repository as a mirror of the repository,
+// both as ORGANIZATION/REPOSITORY. Their Issues with the same number and
+// title are only returned once by Search.
+func (mp *MultiProvider) AddMirror(repository string, mirror string) {
+	if mp.mirrors == nil {
+		mp.mirrors = make(map[string]string)
+	}
+	mp.mirrors[strings.ToLower(mirror)] = strings.ToLower(repository)
+}
+
+// Authorize gets authentication information from all the providers and
+// returns the user of the first one
+func (mp *MultiProvider) Authorize(ctx context.Context) (*User, error) {
+	if len(mp.sources) == 0 {
+		return nil, fmt.Errorf("multi: no providers")
+	}
+	var result *User
+	for _, source := range mp.sources {
+		user, err := source.provider.Authorize(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", source.name, err)
+		}
+		if result == nil {
+			result = user
+		}
+	}
+	return result, nil
+}
+
+// Search queries all the providers concurrently and returns their Issues in
+// a single page.
+//
+// Each Issue is tagged with the name of its provider as Source. Mirrored
+// issues, the ones with the same organization, repository, number and title
+// once the mirrors of AddMirror are resolved, are only returned for the
+// first provider added. The Issues are ordered by provider,
+// organization, repository and number.
+//
+// If some providers can't retrieve all their Issues, the Issues retrieved
+// are returned with an IncompleteResultsError that counts the results of
+// all these providers.
+func (mp *MultiProvider) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
+	results := make([][]Issue, len(mp.sources))
+	errs := make([]error, len(mp.sources))
+	var wg sync.WaitGroup
+	for i, source := range mp.sources {
+		wg.Add(1)
+		go func(i int, source multiProviderSource) {
+			defer wg.Done()
+			results[i], errs[i] = searchAll(ctx, source.provider, query, options)
+		}(i, source)
+	}
+	wg.Wait()
+
+	// the incomplete results of the providers are merged with the others
+	var incomplete *IncompleteResultsError
+	for i, err := range errs {
+		if partial, ok := err.(*IncompleteResultsError); ok {
+			if incomplete == nil {
+				incomplete = &IncompleteResultsError{Query: query}
+			}
+			incomplete.Total += partial.Total
+			incomplete.Retrieved += partial.Retrieved
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", mp.sources[i].name, err)
+		}
+	}
+
+	// merge the results in the order the providers were added
+	type mergedIssue struct {
+		issue        Issue
+		source       int
+		organization string
+		repository   string
+	}
+	var merged []mergedIssue
+	seen := make(map[string]bool)
+	for i, issues := range results {
+		for _, issue := range issues {
+			issue.Source = mp.sources[i].name
+			organization, _ := issue.GetOrganization()
+			repository, _ := issue.GetRepository()
+
+			fullName := strings.ToLower(organization + "/" + repository)
+			if mirrored, ok := mp.mirrors[fullName]; ok {
+				fullName = mirrored
+			}
+			mirror := strings.ToLower(fmt.Sprintf("%s#%d %s", fullName, issue.Number, issue.Title))
+			if seen[mirror] {
+				continue
+			}
+			seen[mirror] = true
+
+			merged = append(merged, mergedIssue{
+				issue:        issue,
+				source:       i,
+				organization: strings.ToLower(organization),
+				repository:   strings.ToLower(repository),
+			})
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if a.source != b.source {
+			return a.source < b.source
+		}
+		if a.organization != b.organization {
+			return a.organization < b.organization
+		}
+		if a.repository != b.repository {
+			return a.repository < b.repository
+		}
+		return a.issue.Number < b.issue.Number
+	})
+
+	result := &SearchResult{}
+	for _, v := range merged {
+		result.Issues = append(result.Issues, v.issue)
+	}
+	if incomplete != nil {
+		return result, incomplete
+	}
+	return result, nil
+}
+
+// source returns the provider of an Issue from its Source
+func (mp *MultiProvider) source(issue *Issue) (IssueProvider, error) {
+	for _, source := range mp.sources {
+		if source.name == issue.Source {
+			return source.provider, nil
+		}
+	}
+	return nil, fmt.Errorf("multi: unknown source %q", issue.Source)
+}
+
+// Comments returns the comments of the Issue from the provider of its Source
+func (mp *MultiProvider) Comments(ctx context.Context, issue *Issue) ([]Comment, error) {
+	provider, err := mp.source(issue)
+	if err != nil {
+		return nil, err
+	}
+	commentsProvider, ok := provider.(CommentsProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s: comments aren't supported", issue.Source)
+	}
+	return commentsProvider.Comments(ctx, issue)
+}
+
+// Timeline returns the timeline events of the Issue from the provider of its
+// Source
+func (mp *MultiProvider) Timeline(ctx context.Context, issue *Issue) ([]Event, error) {
+	provider, err := mp.source(issue)
+	if err != nil {
+		return nil, err
+	}
+	timelineProvider, ok := provider.(TimelineProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s: timelines aren't supported", issue.Source)
+	}
+	return timelineProvider.Timeline(ctx, issue)
+}
+
+// LinkedPullRequests returns the linked pull requests of the Issue from the
+// provider of its Source
+func (mp *MultiProvider) LinkedPullRequests(ctx context.Context, issue *Issue) ([]LinkedPullRequest, error) {
+	provider, err := mp.source(issue)
+	if err != nil {
+		return nil, err
+	}
+	pullRequestsProvider, ok := provider.(LinkedPullRequestsProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s: linked pull requests aren't supported", issue.Source)
+	}
+	return pullRequestsProvider.LinkedPullRequests(ctx, issue)
+}
+
+// ReviewState returns the review state of the pull request from the
+// provider of its Source
+func (mp *MultiProvider) ReviewState(ctx context.Context, issue *Issue) (string, error) {
+	provider, err := mp.source(issue)
+	if err != nil {
+		return "", err
+	}
+	reviewStateProvider, ok := provider.(ReviewStateProvider)
+	if !ok {
+		return "", fmt.Errorf("%s: review states aren't supported", issue.Source)
+	}
+	return reviewStateProvider.ReviewState(ctx, issue)
+}
+
+// SubIssues returns the sub-issues of the Issue from the provider of its
+// Source
+func (mp *MultiProvider) SubIssues(ctx context.Context, issue *Issue, options *SearchOptions) ([]Issue, error) {
+	provider, err := mp.source(issue)
+	if err != nil {
+		return nil, err
+	}
+	hierarchyProvider, ok := provider.(HierarchyProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s: sub-issues aren't supported", issue.Source)
+	}
+	result, err := hierarchyProvider.SubIssues(ctx, issue, options)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].Source = issue.Source
+	}
+	return result, nil
+}
+
+// ValidateQuery validates the query for all the providers
+func (mp *MultiProvider) ValidateQuery(query string) error {
+	for _, source := range mp.sources {
+		if err := validateQuery(source.provider, query); err != nil {
+			return fmt.Errorf("%s: %v", source.name, err)
+		}
+	}
+	return nil
+}
+
+// Teams returns the teams of the providers that have teams, without
+// duplicates
+func (mp *MultiProvider) Teams(ctx context.Context) ([]string, error) {
+	var result []string
+	seen := map[string]bool{}
+	for _, source := range mp.sources {
+		teamsProvider, ok := source.provider.(TeamsProvider)
+		if !ok {
+			continue
+		}
+		teams, err := teamsProvider.Teams(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", source.name, err)
+		}
+		for _, team := range teams {
+			if !seen[team] {
+				seen[team] = true
+				result = append(result, team)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+func TestMultiProviderAuthorize(t *testing.T) {
+	provider := issues2markdown.NewMultiProvider()
+	provider.Add("github", &fakeProvider{user: &issues2markdown.User{Login: "username"}})
+	provider.Add("gitlab", &fakeProvider{user: &issues2markdown.User{Login: "other"}})
+
+	user, err := provider.Authorize(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Login != "username" {
+		t.Fatalf("Expected login %q but got %q", "username", user.Login)
+	}
+
+	provider.Add("jira", &fakeProvider{})
+	_, err = provider.Authorize(context.Background())
+	if err == nil {
+		t.Fatal("Expected an authorization error")
+	}
+}
+
+func TestMultiProviderSearch(t *testing.T) {
+	github := &fakeProvider{
+		user: &issues2markdown.User{Login: "username"},
+		issues: []issues2markdown.Issue{
+			{Number: 2, Title: "Issue title 2", State: "open", URL: "https://api.github.com/repos/username/repo/issues/2"},
+			{Number: 1, Title: "Issue title 1", State: "closed", URL: "https://api.github.com/repos/username/repo/issues/1"},
+			{Number: 5, Title: "Issue title 5", State: "open", URL: "https://api.github.com/repos/another/repo/issues/5"},
+		},
+	}
+	gitlab := &fakeProvider{
+		user: &issues2markdown.User{Login: "username"},
+		issues: []issues2markdown.Issue{
+			{Number: 3, Title: "Issue title 3", State: "open", Organization: "group", Repository: "project"},
+			{Number: 1, Title: "Issue title 1", State: "closed", Organization: "mirrors", Repository: "repo"},
+		},
+	}
+	provider := issues2markdown.NewMultiProvider()
+	provider.Add("github", github)
+	provider.Add("gitlab", gitlab)
+	provider.AddMirror("username/repo", "mirrors/repo")
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NextPage != "" {
+		t.Fatalf("Expected a single page but got next page %q", result.NextPage)
+	}
+	if len(github.queries) != 3 || len(gitlab.queries) != 2 {
+		t.Fatalf("Expected every page of every provider to be queried but got %v and %v", github.queries, gitlab.queries)
+	}
+
+	expected := []struct {
+		source string
+		number int
+	}{
+		{"github", 5},
+		{"github", 1},
+		{"github", 2},
+		{"gitlab", 3},
+	}
+	if len(result.Issues) != len(expected) {
+		t.Fatalf("Expected %d issues but got %+v", len(expected), result.Issues)
+	}
+	for i, issue := range result.Issues {
+		if issue.Source != expected[i].source || issue.Number != expected[i].number {
+			t.Fatalf("Expected issue %d to be %s #%d but got %s #%d", i, expected[i].source, expected[i].number, issue.Source, issue.Number)
+		}
+	}
+}
+
+func TestMultiProviderSearchSameRepositoryName(t *testing.T) {
+	github := &fakeProvider{
+		user: &issues2markdown.User{Login: "username"},
+		issues: []issues2markdown.Issue{
+			{Number: 1, Title: "Update README", State: "open", Organization: "org1", Repository: "docs"},
+		},
+	}
+	gitlab := &fakeProvider{
+		user: &issues2markdown.User{Login: "username"},
+		issues: []issues2markdown.Issue{
+			{Number: 1, Title: "Update README", State: "open", Organization: "org2", Repository: "docs"},
+		},
+	}
+	provider := issues2markdown.NewMultiProvider()
+	provider.Add("github", github)
+	provider.Add("gitlab", gitlab)
+
+	result, err := provider.Search(context.Background(), "is:open", &issues2markdown.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) != 2 {
+		t.Fatalf("Expected the issues of both organizations but got %+v", result.Issues)
+	}
+	if result.Issues[0].Organization != "org1" || result.Issues[1].Organization != "org2" {
+		t.Fatalf("Expected the issues of org1 and org2 but got %+v", result.Issues)
+	}
+}
+
+func TestMultiProviderComments(t *testing.T) {
+	provider := issues2markdown.NewMultiProvider()
+	provider.Add("github", newFakeEnrichProvider(1))
+	provider.Add("jira", &fakeProvider{user: &issues2markdown.User{Login: "username"}})
+
+	comments, err := provider.Comments(context.Background(), &issues2markdown.Issue{Number: 2, Source: "github"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("Expected %d comments but got %d", 2, len(comments))
+	}
+	events, err := provider.Timeline(context.Background(), &issues2markdown.Issue{Number: 2, Source: "github"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected %d events but got %d", 3, len(events))
+	}
+
+	if _, err := provider.Comments(context.Background(), &issues2markdown.Issue{Number: 1, Source: "jira"}); err == nil {
+		t.Fatal("Expected an error for a provider without comments")
+	}
+	if _, err := provider.Timeline(context.Background(), &issues2markdown.Issue{Number: 1, Source: "unknown"}); err == nil {
+		t.Fatal("Expected an error for an unknown source")
+	}
+}
+
+func TestMultiProviderTeams(t *testing.T) {
+	provider := issues2markdown.NewMultiProvider()
+	provider.Add("github", &fakeTeamsProvider{teams: []string{"organization/backend", "organization/frontend"}})
+	provider.Add("jira", &fakeProvider{user: &issues2markdown.User{Login: "username"}})
+	provider.Add("mirror", &fakeTeamsProvider{teams: []string{"organization/backend", "other/team"}})
+
+	teams, err := provider.Teams(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTeams := []string{"organization/backend", "organization/frontend", "other/team"}
+	if !reflect.DeepEqual(teams, expectedTeams) {
+		t.Fatalf("Expected teams %q but got %q", expectedTeams, teams)
+	}
+}
+
+func TestMultiProviderQueryIncompleteResults(t *testing.T) {
+	// the issues created in the same second can't be split
+	provider := issues2markdown.NewMultiProvider()
+	provider.Add("github", newFakeLimitProvider(12, 0))
+	other := &fakeProvider{user: &issues2markdown.User{Login: "username"}}
+	other.issues = []issues2markdown.Issue{newTreeIssue(1, "other")}
+	provider.Add("gitlab", other)
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "repo:username/repo")
+	incomplete, ok := err.(*issues2markdown.IncompleteResultsError)
+	if !ok {
+		t.Fatalf("Expected an IncompleteResultsError but got %v", err)
+	}
+	if incomplete.Total != 12 || incomplete.Retrieved != 10 {
+		t.Fatalf("Expected %d of %d results retrieved but got %+v", 10, 12, incomplete)
+	}
+	if len(issues) != 11 {
+		t.Fatalf("Expected the %d issues retrieved but got %d", 11, len(issues))
+	}
+	if issues[10].Source != "gitlab" {
+		t.Fatalf("Expected the issue of the other provider last but got %+v", issues[10])
+	}
+}
@@ -0,0 +1,473 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchQueryError is an error of a term of a search query
+type SearchQueryError struct {
+	// Query is the search query
+	Query string
+	// Offset is the byte position of the term in the query
+	Offset  int
+	Term    string
+	Message string
+}
+
+// Error returns the message with the position of the term
+func (e *SearchQueryError) Error() string {
+	return fmt.Sprintf("query %s: %s at offset %d: %s", strconv.Quote(e.Query), strconv.Quote(e.Term), e.Offset, e.Message)
+}
+
+// SearchQueryErrors are all the errors of a search query, in the order of
+// the terms
+type SearchQueryErrors []*SearchQueryError
+
+// Error returns the messages of all the errors
+func (e SearchQueryErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// searchQualifiers validates the values of the known search qualifiers, the
+// qualifiers without validation accept any value
+var searchQualifiers = map[string]func(value string) error{
+	"is":                    searchValueIn("open", "closed", "issue", "pr", "merged", "unmerged", "draft", "locked", "unlocked", "public", "private", "archived", "queued", "blocked", "blocking"),
+	"state":                 searchValueIn("open", "closed"),
+	"status":                searchValueIn("success", "failure", "pending"),
+	"type":                  nil,
+	"repo":                  nil,
+	"org":                   nil,
+	"user":                  nil,
+	"label":                 nil,
+	"milestone":             nil,
+	"assignee":              nil,
+	"author":                nil,
+	"mentions":              nil,
+	"commenter":             nil,
+	"involves":              nil,
+	"team":                  nil,
+	"project":               nil,
+	"language":              nil,
+	"head":                  nil,
+	"base":                  nil,
+	"reviewed-by":           nil,
+	"review-requested":      nil,
+	"team-review-requested": nil,
+	"user-review-requested": nil,
+	"parent-issue":          nil,
+	"review":                searchValueIn("none", "required", "approved", "changes_requested"),
+	"in":                    searchValuesIn("title", "body", "comments"),
+	"has":                   searchValueIn("parent-issue", "sub-issue"),
+	"no":                    searchValueIn("label", "milestone", "assignee", "project", "type", "parent-issue", "sub-issue"),
+	"linked":                searchValueIn("pr", "issue"),
+	"reason":                searchValueIn("completed", "not planned", "reopened"),
+	"archived":              searchValueIn("true", "false"),
+	"draft":                 searchValueIn("true", "false"),
+	"created":               validateSearchDate,
+	"updated":               validateSearchDate,
+	"closed":                validateSearchDate,
+	"merged":                validateSearchDate,
+	"comments":              validateSearchNumber,
+	"reactions":             validateSearchNumber,
+	"interactions":          validateSearchNumber,
+	"sort":                  nil,
+}
+
+// searchConflicts are the values of the qualifiers that exclude each other,
+// by the property they select
+var searchConflicts = map[string]map[string]string{
+	"is": {
+		"open":     "state",
+		"closed":   "state",
+		"issue":    "type",
+		"pr":       "type",
+		"merged":   "merged",
+		"unmerged": "merged",
+		"public":   "visibility",
+		"private":  "visibility",
+		"locked":   "locked",
+		"unlocked": "locked",
+	},
+	"state": {
+		"open":   "state",
+		"closed": "state",
+	},
+	"type": {
+		"issue": "type",
+		"pr":    "type",
+	},
+	"archived": {
+		"true":  "archived",
+		"false": "archived",
+	},
+	"draft": {
+		"true":  "draft",
+		"false": "draft",
+	},
+}
+
+// searchKeyRe matches the keys of the qualifiers, with the dash of the
+// negated ones
+var searchKeyRe = regexp.MustCompile(`^-?[a-z][a-z-]*$`)
+
+// searchDateLayouts are the layouts of the dates of the search qualifiers
+var searchDateLayouts = []string{
+	searchDateLayout,
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+}
+
+// searchToken is a token of a search query: a term, a parenthesis or a
+// keyword
+type searchToken struct {
+	value  string
+	offset int
+}
+
+// searchQueryParser parses the tokens of a search query
+type searchQueryParser struct {
+	query  string
+	tokens []searchToken
+	pos    int
+	errs   SearchQueryErrors
+	// selected are the qualifiers of the properties with conflicting values
+	selected map[string]Qualifier
+	// lenient keeps the unknown qualifiers without validating the query
+	lenient bool
+}
+
+// ParseSearchQuery parses a query in the Github search syntax and validates
+// it. It reports the unknown qualifiers, the malformed values and the terms
+// that conflict with others, like is:open is:closed, as SearchQueryErrors.
+func ParseSearchQuery(q string) (*SearchQuery, error) {
+	p := &searchQueryParser{
+		query:    q,
+		selected: make(map[string]Qualifier),
+	}
+	p.tokens = p.tokenize(q)
+	terms := p.parseTerms(false)
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+	return NewSearchQuery(terms...), nil
+}
+
+// fail records an error of the token
+func (p *searchQueryParser) fail(token searchToken, format string, args ...interface{}) {
+	p.errs = append(p.errs, &SearchQueryError{
+		Query:   p.query,
+		Offset:  token.offset,
+		Term:    token.value,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// tokenize splits a search query by spaces and parentheses not enclosed in
+// quotes
+func (p *searchQueryParser) tokenize(q string) []searchToken {
+	var tokens []searchToken
+	start := -1
+	quote := -1
+	flush := func(end int) {
+		if start != -1 {
+			tokens = append(tokens, searchToken{value: q[start:end], offset: start})
+			start = -1
+		}
+	}
+	for i, r := range q {
+		switch {
+		case quote != -1:
+			if r == '"' {
+				quote = -1
+			}
+		case r == '"':
+			if start == -1 {
+				start = i
+			}
+			quote = i
+		case r == '(' || r == ')':
+			flush(i)
+			tokens = append(tokens, searchToken{value: string(r), offset: i})
+		case unicode.IsSpace(r):
+			flush(i)
+		default:
+			if start == -1 {
+				start = i
+			}
+		}
+	}
+	if quote != -1 {
+		p.fail(searchToken{value: q[start:], offset: start}, "unterminated quote at offset %d", quote)
+	}
+	flush(len(q))
+	return tokens
+}
+
+// peek returns the next token, if any
+func (p *searchQueryParser) peek() (searchToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return searchToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// parseTerms parses the terms up to the end of the query or, inside a
+// group, up to its closing parenthesis
+func (p *searchQueryParser) parseTerms(group bool) []Term {
+	var terms []Term
+	for {
+		token, ok := p.peek()
+		if !ok {
+			return terms
+		}
+		switch token.value {
+		case ")":
+			if group {
+				return terms
+			}
+			p.pos++
+			p.fail(token, "unbalanced parenthesis")
+			continue
+		case "AND":
+			// the terms are all required to match without it
+			p.pos++
+			continue
+		case "OR":
+			p.pos++
+			p.fail(token, "OR without a term before it")
+			continue
+		}
+		term := p.parseOr()
+		if term == nil {
+			continue
+		}
+		if q, ok := term.(Qualifier); ok && !group && !p.lenient {
+			p.checkConflict(q, token)
+		}
+		terms = append(terms, term)
+	}
+}
+
+// parseOr parses a term and the terms joined to it by OR
+func (p *searchQueryParser) parseOr() Term {
+	var terms []Term
+	if term := p.parseTerm(); term != nil {
+		terms = append(terms, term)
+	}
+	for {
+		token, ok := p.peek()
+		if !ok || token.value != "OR" {
+			break
+		}
+		p.pos++
+		next, ok := p.peek()
+		if !ok || next.value == ")" || next.value == "OR" {
+			p.fail(token, "OR without a term after it")
+			break
+		}
+		if term := p.parseTerm(); term != nil {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return Or(terms...)
+}
+
+// parseTerm parses a group, a negated term, a qualifier or a text
+func (p *searchQueryParser) parseTerm() Term {
+	token, _ := p.peek()
+	p.pos++
+	switch token.value {
+	case "(":
+		terms := p.parseTerms(true)
+		if closing, ok := p.peek(); ok && closing.value == ")" {
+			p.pos++
+		} else {
+			p.fail(token, "unbalanced parenthesis")
+		}
+		if len(terms) == 1 {
+			return terms[0]
+		}
+		return AndTerm(terms)
+	case "NOT":
+		next, ok := p.peek()
+		if !ok || next.value == "(" || next.value == ")" || next.value == "OR" || next.value == "NOT" {
+			p.fail(token, "NOT without a term after it")
+			return nil
+		}
+		p.pos++
+		switch term := p.parseWord(next).(type) {
+		case Qualifier:
+			return Not(term)
+		case Text:
+			return ExcludedText(term)
+		}
+		return nil
+	}
+	return p.parseWord(token)
+}
+
+// parseWord parses a qualifier or a text
+func (p *searchQueryParser) parseWord(token searchToken) Term {
+	idx := strings.Index(token.value, ":")
+	if idx <= 0 || strings.HasPrefix(token.value, "\"") {
+		return Text(unquoteSearchValue(token.value))
+	}
+	key := strings.ToLower(token.value[:idx])
+	negated := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	value := unquoteSearchValue(token.value[idx+1:])
+	validate, ok := searchQualifiers[key]
+	if !ok {
+		// the terms that aren't qualifiers, like the URLs, are text
+		if !searchKeyRe.MatchString(key) || strings.HasPrefix(value, "//") {
+			return Text(unquoteSearchValue(token.value))
+		}
+		if !p.lenient {
+			p.fail(token, "unknown qualifier %q", key)
+			return nil
+		}
+	}
+	if p.lenient {
+		if value == "" {
+			return Text(unquoteSearchValue(token.value))
+		}
+		return Qualifier{Key: key, Value: value, Negated: negated}
+	}
+	if value == "" {
+		p.fail(token, "missing value of qualifier %q", key)
+		return nil
+	}
+	if validate != nil {
+		if err := validate(value); err != nil {
+			p.fail(token, "%v", err)
+			return nil
+		}
+	}
+	return Qualifier{Key: key, Value: value, Negated: negated}
+}
+
+// checkConflict records an error for a qualifier that selects another value
+// of a property than a previous qualifier. Only the required terms conflict,
+// not the alternatives of an OR.
+func (p *searchQueryParser) checkConflict(q Qualifier, token searchToken) {
+	if q.Negated {
+		return
+	}
+	value := strings.ToLower(q.Value)
+	property, ok := searchConflicts[q.Key][value]
+	if !ok {
+		return
+	}
+	if previous, ok := p.selected[property]; ok && !strings.EqualFold(previous.Value, q.Value) {
+		p.fail(token, "conflicts with %s", previous)
+		return
+	}
+	p.selected[property] = q
+}
+
+// unquoteSearchValue removes the quotes of a value
+func unquoteSearchValue(value string) string {
+	return strings.Replace(value, "\"", "", -1)
+}
+
+// searchValueIn returns a validation of the values, compared without case
+func searchValueIn(values ...string) func(value string) error {
+	return func(value string) error {
+		for _, v := range values {
+			if strings.EqualFold(v, value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid value %q, expected one of %s", value, strings.Join(values, ", "))
+	}
+}
+
+// searchValuesIn returns a validation of the comma separated values,
+// compared without case
+func searchValuesIn(values ...string) func(value string) error {
+	validate := searchValueIn(values...)
+	return func(value string) error {
+		for _, v := range strings.Split(value, ",") {
+			if err := validate(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// validateSearchDate validates a date qualifier value: DATE, >DATE, >=DATE,
+// <DATE, <=DATE or FROM..TO, where FROM or TO can be *
+func validateSearchDate(value string) error {
+	return validateSearchRange(value, func(v string) bool {
+		for _, layout := range searchDateLayouts {
+			if _, err := time.Parse(layout, v); err == nil {
+				return true
+			}
+		}
+		return false
+	}, "date")
+}
+
+// validateSearchNumber validates a number qualifier value: N, >N, >=N, <N,
+// <=N or FROM..TO, where FROM or TO can be *
+func validateSearchNumber(value string) error {
+	return validateSearchRange(value, func(v string) bool {
+		n, err := strconv.Atoi(v)
+		return err == nil && n >= 0
+	}, "number")
+}
+
+// validateSearchRange validates a value or a range of values
+func validateSearchRange(value string, valid func(v string) bool, kind string) error {
+	if idx := strings.Index(value, ".."); idx != -1 {
+		from, to := value[:idx], value[idx+2:]
+		if (from == "*" || valid(from)) && (to == "*" || valid(to)) {
+			return nil
+		}
+		return fmt.Errorf("malformed %s range %q", kind, value)
+	}
+	for _, operator := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(value, operator) {
+			value = strings.TrimPrefix(value, operator)
+			break
+		}
+	}
+	if !valid(value) {
+		return fmt.Errorf("malformed %s %q", kind, value)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		q     string
+		query string
+	}{
+		{"is:open author:username archived:false", "is:open author:username archived:false"},
+		{`label:"good first issue" -label:wontfix`, `label:"good first issue" -label:wontfix`},
+		{`crash "out of memory"`, `crash "out of memory"`},
+		{"NOT flaky NOT label:bug", "NOT flaky -label:bug"},
+		{"label:bug OR label:docs is:open", "(label:bug OR label:docs) is:open"},
+		{"(label:bug is:open) OR (label:docs AND is:closed)", "((label:bug is:open) OR (label:docs is:closed))"},
+		{"Is:Open STATE:closed OR is:open", "is:Open (state:closed OR is:open)"},
+		{"created:>=2018-10-01 updated:2018-10-01..2018-10-09 closed:<2018-10-09T10:00:00Z merged:*..2018-10-09", "created:>=2018-10-01 updated:2018-10-01..2018-10-09 closed:<2018-10-09T10:00:00Z merged:*..2018-10-09"},
+		{"comments:>10 reactions:5..20", "comments:>10 reactions:5..20"},
+		{"in:title,body status:success user-review-requested:@me", "in:title,body status:success user-review-requested:@me"},
+		{"has:parent-issue parent-issue:username/repo#1 is:blocked", "has:parent-issue parent-issue:username/repo#1 is:blocked"},
+		{"crash https://example.com 10:30", "crash https://example.com 10:30"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		query, err := issues2markdown.ParseSearchQuery(test.q)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", test.q, err)
+		}
+		if got := query.String(); got != test.query {
+			t.Fatalf("Expected %q to parse as %q but got %q", test.q, test.query, got)
+		}
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	tests := []struct {
+		q       string
+		offsets []int
+		message string
+	}{
+		{"is:opne", []int{0}, `invalid value "opne", expected one of open, closed, issue, pr, merged, unmerged, draft, locked, unlocked, public, private, archived, queued, blocked, blocking`},
+		{"in:title,bdy", []int{0}, `invalid value "bdy", expected one of title, body, comments`},
+		{"is:open colour:red", []int{8}, `unknown qualifier "colour"`},
+		{"label: is:open", []int{0}, `missing value of qualifier "label"`},
+		{"created:2018-13-01", []int{0}, `malformed date "2018-13-01"`},
+		{"updated:2018-10-01..yesterday", []int{0}, `malformed date range "2018-10-01..yesterday"`},
+		{"comments:>many", []int{0}, `malformed number "many"`},
+		{"is:open label:bug is:closed", []int{18}, "conflicts with is:open"},
+		{"type:issue is:pr", []int{11}, "conflicts with type:issue"},
+		{"archived:false archived:true", []int{15}, "conflicts with archived:false"},
+		{`label:"good first issue`, []int{0}, "unterminated quote at offset 6"},
+		{"(label:bug", []int{0}, "unbalanced parenthesis"},
+		{"label:bug)", []int{9}, "unbalanced parenthesis"},
+		{"OR label:bug", []int{0}, "OR without a term before it"},
+		{"label:bug OR", []int{10}, "OR without a term after it"},
+		{"label:bug NOT", []int{10}, "NOT without a term after it"},
+		{"is:opne colour:red created:soon", []int{0, 8, 19}, ""},
+	}
+	for _, test := range tests {
+		_, err := issues2markdown.ParseSearchQuery(test.q)
+		errs, ok := err.(issues2markdown.SearchQueryErrors)
+		if !ok {
+			t.Fatalf("Expected SearchQueryErrors for %q but got %v", test.q, err)
+		}
+		if len(errs) != len(test.offsets) {
+			t.Fatalf("Expected %d errors for %q but got %v", len(test.offsets), test.q, errs)
+		}
+		for i, offset := range test.offsets {
+			if errs[i].Offset != offset {
+				t.Fatalf("Expected error %d of %q at offset %d but got %v", i, test.q, offset, errs[i])
+			}
+		}
+		if test.message != "" && errs[0].Message != test.message {
+			t.Fatalf("Expected message %q for %q but got %q", test.message, test.q, errs[0].Message)
+		}
+	}
+}
+
+func TestParseSearchQueryError(t *testing.T) {
+	_, err := issues2markdown.ParseSearchQuery("is:open is:closed")
+	expected := `query "is:open is:closed": "is:closed" at offset 8: conflicts with is:open`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected error %q but got %v", expected, err)
+	}
+}
+
+func TestQueryInvalidSearchQuery(t *testing.T) {
+	provider := &fakeProvider{user: &issues2markdown.User{Login: "username"}}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	options.Vars = map[string]string{"state": "open"}
+	tests := map[string]string{
+		"is:opne":                    `query "is:opne": "is:opne" at offset 0`,
+		"is:{{ .Vars.state }} is:up": `query "is:open is:up": "is:up" at offset 8`,
+	}
+	for q, expected := range tests {
+		_, err := i2md.Query(options, q)
+		if err == nil || !strings.HasPrefix(err.Error(), expected) {
+			t.Fatalf("Expected error %q for %q but got %v", expected, q, err)
+		}
+	}
+	if len(provider.queries) != 0 {
+		t.Fatalf("Expected no search but got %v", provider.queries)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// IssueProvider is the interface implemented by the issue trackers that can
+// be queried by IssuesToMarkdown
+type IssueProvider interface {
+	// Authorize gets authentication information for the credentials used by
+	// the provider
+	Authorize(ctx context.Context) (*User, error)
+	// Search returns a page of the Issues that match the query. The Issues
+	// retrieved can be returned with an IncompleteResultsError if the
+	// provider can't retrieve all of them.
+	Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error)
+}
+
+// CommentsProvider is implemented by the providers that can list the
+// comments of an Issue
+type CommentsProvider interface {
+	// Comments returns all the comments of the Issue, oldest first
+	Comments(ctx context.Context, issue *Issue) ([]Comment, error)
+}
+
+// TimelineProvider is implemented by the providers that can list the
+// timeline events of an Issue
+type TimelineProvider interface {
+	// Timeline returns all the events of the Issue, oldest first
+	Timeline(ctx context.Context, issue *Issue) ([]Event, error)
+}
+
+// LinkedPullRequestsProvider is implemented by the providers that can list
+// the pull requests that reference or close an Issue
+type LinkedPullRequestsProvider interface {
+	// LinkedPullRequests returns the pull requests that reference the Issue,
+	// oldest reference first
+	LinkedPullRequests(ctx context.Context, issue *Issue) ([]LinkedPullRequest, error)
+}
+
+// ReviewStateProvider is implemented by the providers that don't return the
+// review state of the pull requests in their searches
+type ReviewStateProvider interface {
+	// ReviewState returns the review state of the pull request, one of the
+	// ReviewState constants or the empty string
+	ReviewState(ctx context.Context, issue *Issue) (string, error)
+}
+
+// HierarchyProvider is implemented by the providers that have sub-issues
+type HierarchyProvider interface {
+	// SubIssues returns the children of the Issue, with the search options
+	// but the Page
+	SubIssues(ctx context.Context, issue *Issue, options *SearchOptions) ([]Issue, error)
+}
+
+// QueryValidator is implemented by the providers whose queries aren't only
+// in the Github search syntax, like the raw JQL or WIQL queries. Query
+// validates the queries of these providers with ValidateQuery instead of
+// ParseSearchQuery.
+type QueryValidator interface {
+	// ValidateQuery returns an error if the provider can't search the query
+	ValidateQuery(query string) error
+}
+
+// validateQuery validates the query with the QueryValidator of the provider,
+// or with ParseSearchQuery
+func validateQuery(provider IssueProvider, query string) error {
+	if validator, ok := provider.(QueryValidator); ok {
+		return validator.ValidateQuery(query)
+	}
+	_, err := ParseSearchQuery(query)
+	return err
+}
+
+// TeamsProvider is implemented by the providers whose users are members of
+// teams
+type TeamsProvider interface {
+	// Teams returns the teams of the authorized user as ORGANIZATION/TEAM
+	Teams(ctx context.Context) ([]string, error)
+}
+
+// SearchLimitProvider is implemented by the providers whose searches return
+// a limited number of results, like the 1000 results of the Github search.
+// The queries with more results are split by created time.
+type SearchLimitProvider interface {
+	// SearchLimit returns the maximum number of results of a search
+	SearchLimit() int
+}
+
+// SearchOptions are the available options to modify a provider search
+type SearchOptions struct {
+	// Page is the provider specific token of the page to retrieve. The empty
+	// value retrieves the first page.
+	Page string
+	// IncludeBody requests the Issue bodies from the providers that don't
+	// return them by default
+	IncludeBody bool
+	// Hierarchy requests the issue types, parents and sub-issues from the
+	// providers that don't return them by default
+	Hierarchy bool
+}
+
+// SearchResult represents a page of Issues returned by a provider search
+type SearchResult struct {
+	Issues []Issue
+	// NextPage is the provider specific token of the next page of results.
+	// The empty value means there are no more pages.
+	NextPage string
+	// Total is the number of Issues that match the query, if the provider
+	// counts them, even above its SearchLimit
+	Total int
+	// Incomplete is whether the provider didn't search all the Issues, like
+	// when the Github search times out
+	Incomplete bool
+}
+
+// searchAll queries the provider following the pagination until all the
+// Issues that match the query are retrieved, starting from the first page
+// whatever the page of the options.
+//
+// The queries of a SearchLimitProvider with more results than its limit, or
+// incomplete results, are split by created time until every slice fits. If a
+// slice can't be split anymore the Issues retrieved are returned with an
+// IncompleteResultsError.
+func searchAll(ctx context.Context, provider IssueProvider, query string, searchOptions *SearchOptions) ([]Issue, error) {
+	options := SearchOptions{}
+	if searchOptions != nil {
+		options = *searchOptions
+	}
+	options.Page = ""
+	first, err := provider.Search(ctx, query, &options)
+	if incomplete, ok := err.(*IncompleteResultsError); ok && first != nil {
+		issues, err := searchPages(ctx, provider, query, options, first)
+		if err != nil {
+			return nil, err
+		}
+		return issues, incomplete
+	}
+	if err != nil {
+		return nil, err
+	}
+	limiter, ok := provider.(SearchLimitProvider)
+	if !ok || !searchExceeds(first, limiter.SearchLimit()) {
+		return searchPages(ctx, provider, query, options, first)
+	}
+	splitter, ok := newSearchSplitter(provider, query, options, limiter.SearchLimit())
+	if !ok {
+		issues, err := searchPages(ctx, provider, query, options, first)
+		if err != nil {
+			return nil, err
+		}
+		return issues, newIncompleteResultsError(query, first, issues)
+	}
+	return splitter.search(ctx)
+}
+
+// searchPages retrieves the pages of results after the first one
+func searchPages(ctx context.Context, provider IssueProvider, query string, options SearchOptions, first *SearchResult) ([]Issue, error) {
+	result := first.Issues
+	page := first
+	for page.NextPage != "" {
+		options.Page = page.NextPage
+		var err error
+		page, err = provider.Search(ctx, query, &options)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, page.Issues...)
+	}
+	return result, nil
+}
+
+// ErrorResponse reports an error response returned by a provider API
+type ErrorResponse struct {
+	Response *http.Response
+	Message  string
+}
+
+func (r *ErrorResponse) Error() string {
+	return fmt.Sprintf("%v %v: %d %v",
+		r.Response.Request.Method, r.Response.Request.URL,
+		r.Response.StatusCode, r.Message)
+}
+
+// doJSON sends an API request and decodes the JSON response body into v
+//
+// Responses with a status code outside the 2xx range are reported as an
+// ErrorResponse.
+func doJSON(ctx context.Context, client *http.Client, req *http.Request, v interface{}) (*http.Response, error) {
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		data, _ := ioutil.ReadAll(response.Body)
+		return response, &ErrorResponse{
+			Response: response,
+			Message:  strings.TrimSpace(string(data)),
+		}
+	}
+	if v == nil {
+		return response, nil
+	}
+	err = json.NewDecoder(response.Body).Decode(v)
+	return response, err
+}
+
+// linkNextRe matches the next page URL in a Link header
+var linkNextRe = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextLink returns the URL of the next page advertised in the Link header of
+// the response or the empty string if there is none
+func nextLink(response *http.Response) string {
+	for _, link := range response.Header["Link"] {
+		if m := linkNextRe.FindStringSubmatch(link); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// parseDate parses the dates without time of the providers, like milestone
+// due dates. It returns nil for empty or invalid dates.
+func parseDate(value string) *time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
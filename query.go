@@ -18,46 +18,224 @@
 package issues2markdown
 
 import (
-	"bytes"
 	"fmt"
-	"html/template"
+	"sort"
 	"strings"
+	"time"
 )
 
 const (
-	// DefaultQuery is the default query to be used if none is provided on the
-	// CLI arguments.
-	DefaultQuery = `type:issue is:open author:{{ .Organization }} archived:false`
+	// DefaultQuery is the default query template to be used if none is
+	// provided on the CLI arguments. See QueryTemplateData for the template
+	// fields and functions.
+	DefaultQuery = `{{ with .TypeQualifier }}{{ . }} {{ end }}is:open author:{{ .Organization }} archived:false`
+)
+
+// ItemType is the type of the items selected by a query
+type ItemType int
+
+// Item types, issues are selected by default
+const (
+	ItemTypeIssues ItemType = iota
+	ItemTypePullRequests
+	ItemTypeAll
 )
 
 // QueryOptions are the available options to modify the query of issues
 type QueryOptions struct {
 	Organization string
+	Type         ItemType
+	// Terms are the typed search terms added to the query, like
+	// LabelQualifier("good first issue")
+	Terms []Term
+	// IncludeBody fetches the Issue bodies and counts their tasks
+	IncludeBody bool
+	// ExpandHierarchy adds the sub-issues of the results, recursively. The
+	// provider must be a HierarchyProvider.
+	ExpandHierarchy bool
+	// Config has the named queries. A query that is the name of one of them
+	// is replaced by its NamedQuery.
+	Config *QueryConfig
+	// Template is the query template used if no query nor Terms are
+	// provided, DefaultQuery if empty
+	Template string
+	// User is the authorized user of the query templates, set by Query
+	User *User
+	// Vars are the user supplied variables of the query templates
+	Vars map[string]string
+	// Now returns the current time for the dates of the query templates
+	Now func() time.Time
+	// teams returns the teams of the user, set by Query for the providers
+	// with teams
+	teams func() ([]string, error)
 }
 
 // NewQueryOptions creates a new QueryOptions instance with sensible defaults
 func NewQueryOptions() *QueryOptions {
-	options := &QueryOptions{}
+	options := &QueryOptions{
+		Template: DefaultQuery,
+	}
 	return options
 }
 
 // BuildQuey builds the query string to query issues
 //
-// It modifies the default query according the proviced query options
-func (qo *QueryOptions) BuildQuey(q string) string {
-	query := strings.Builder{}
+// It modifies the default query according the proviced query options. The
+// query is a template too, executed with the QueryTemplateData of the
+// options.
+func (qo *QueryOptions) BuildQuey(q string) (string, error) {
+	query, _, err := qo.buildQuery(q)
+	return query, err
+}
 
+// buildQuery builds the query string to query issues and returns it with
+// the executed template of the query provided, or of the default one, which
+// the query string includes
+func (qo *QueryOptions) buildQuery(q string) (string, string, error) {
 	// If query is none we use the default one
-	if q == "" {
-		var compiled bytes.Buffer
-		t := template.Must(template.New("issueslist").Parse(DefaultQuery))
-		_ = t.Execute(&compiled, qo)
-		return compiled.String()
-	}
-
-	// whe only want issues
-	_, _ = query.WriteString("type:issue")
-	// append queries provided by CLI arguments
-	_, _ = query.WriteString(fmt.Sprintf(" %s", q))
-	return query.String()
+	if q == "" && len(qo.Terms) == 0 {
+		source := qo.Template
+		if source == "" {
+			source = DefaultQuery
+		}
+		executed, err := qo.executeQueryTemplate("query", source)
+		return executed, executed, err
+	}
+
+	// select the type of items, then append the query provided by CLI
+	// arguments and the typed terms
+	executed, err := qo.executeQueryTemplate("query", q)
+	if err != nil {
+		return "", "", err
+	}
+	query := NewSearchQuery(ItemTypeQualifier(qo.Type), Raw(executed))
+	query.Add(qo.Terms...)
+	return query.String(), executed, nil
+}
+
+// TypeQualifier returns the qualifier that selects the type of items of the
+// options, which is empty for all types
+func (qo *QueryOptions) TypeQualifier() string {
+	return ItemTypeQualifier(qo.Type).String()
+}
+
+// searchTerms are the qualifiers and the free text terms of a search query
+type searchTerms struct {
+	qualifiers map[string][]string
+	text       []string
+	// operators are the OR and NOT operators and the groups, which the
+	// providers with flat queries don't support
+	operators []string
+}
+
+// parseSearchTerms splits a search query in qualifiers and free text terms
+//
+// The query is parsed like ParseSearchQuery, but without validation.
+// Qualifiers are the terms in the form key:value, negated qualifiers keep the
+// leading dash in the key.
+func parseSearchTerms(q string) *searchTerms {
+	terms := &searchTerms{
+		qualifiers: make(map[string][]string),
+	}
+	p := &searchQueryParser{
+		query:   q,
+		lenient: true,
+	}
+	p.tokens = p.tokenize(q)
+	terms.add(p.parseTerms(false)...)
+	return terms
+}
+
+// add adds the qualifiers and texts of the terms, and the operators of the
+// others
+func (st *searchTerms) add(terms ...Term) {
+	for _, term := range terms {
+		switch v := term.(type) {
+		case Qualifier:
+			key := v.Key
+			if v.Negated {
+				key = "-" + key
+			}
+			st.qualifiers[key] = append(st.qualifiers[key], v.Value)
+		case Text:
+			st.text = append(st.text, string(v))
+		case ExcludedText:
+			st.operators = append(st.operators, "NOT")
+		case OrTerm:
+			st.operators = append(st.operators, "OR")
+		case AndTerm:
+			st.operators = append(st.operators, "(")
+		default:
+			st.text = append(st.text, term.String())
+		}
+	}
+}
+
+// get returns the last value of the qualifier or the empty string if the
+// qualifier is not present
+func (st *searchTerms) get(key string) string {
+	values := st.qualifiers[key]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// state returns the normalized state requested by the is: and state:
+// qualifiers, "open" or "closed", or the empty string for any state
+func (st *searchTerms) state() string {
+	state := ""
+	for _, key := range []string{"is", "state"} {
+		for _, v := range st.qualifiers[key] {
+			switch strings.ToLower(v) {
+			case "open", "closed":
+				state = strings.ToLower(v)
+			}
+		}
+	}
+	return state
+}
+
+// remove deletes the qualifier from the search terms. If values are provided
+// only the matching values, compared without case, are deleted.
+func (st *searchTerms) remove(key string, values ...string) {
+	if len(values) == 0 {
+		delete(st.qualifiers, key)
+		return
+	}
+	var kept []string
+	for _, v := range st.qualifiers[key] {
+		matched := false
+		for _, value := range values {
+			if strings.EqualFold(v, value) {
+				matched = true
+			}
+		}
+		if !matched {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) == 0 {
+		delete(st.qualifiers, key)
+		return
+	}
+	st.qualifiers[key] = kept
+}
+
+// unsupported returns an error for the first operator or qualifier left in
+// the search terms, which the caller is expected to have removed once
+// processed
+func (st *searchTerms) unsupported(provider string) error {
+	if len(st.operators) > 0 {
+		return fmt.Errorf("%s: unsupported search operator %s", provider, st.operators[0])
+	}
+	keys := make([]string, 0, len(st.qualifiers))
+	for key := range st.qualifiers {
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	return fmt.Errorf("%s: unsupported search qualifier %s:%s", provider, keys[0], st.qualifiers[keys[0]][0])
 }
@@ -38,7 +38,10 @@ func TestBuildQueryDefaultQueryOptions(t *testing.T) {
 	options.Organization = "username"
 
 	expectedQuery := "type:issue is:open author:username archived:false"
-	query := options.BuildQuey("")
+	query, err := options.BuildQuey("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	if query != expectedQuery {
 		t.Fatalf("Default QueryOptions query expected to be %q but got %q", expectedQuery, query)
 	}
@@ -49,8 +52,64 @@ func TestBuildQueryQueryOptions(t *testing.T) {
 	options.Organization = "username"
 
 	expectedQuery := "type:issue repo:organization/repository"
-	query := options.BuildQuey("repo:organization/repository")
+	query, err := options.BuildQuey("repo:organization/repository")
+	if err != nil {
+		t.Fatal(err)
+	}
 	if query != expectedQuery {
 		t.Fatalf("Default QueryOptions query expected to be %q but got %q", expectedQuery, query)
 	}
 }
+
+func TestBuildQueryItemTypes(t *testing.T) {
+	tests := []struct {
+		itemType issues2markdown.ItemType
+		q        string
+		query    string
+	}{
+		{issues2markdown.ItemTypeIssues, "", "type:issue is:open author:username archived:false"},
+		{issues2markdown.ItemTypePullRequests, "", "type:pr is:open author:username archived:false"},
+		{issues2markdown.ItemTypeAll, "", "is:open author:username archived:false"},
+		{issues2markdown.ItemTypeIssues, "repo:organization/repository", "type:issue repo:organization/repository"},
+		{issues2markdown.ItemTypePullRequests, "repo:organization/repository", "type:pr repo:organization/repository"},
+		{issues2markdown.ItemTypeAll, "repo:organization/repository", "repo:organization/repository"},
+	}
+	for _, test := range tests {
+		options := issues2markdown.NewQueryOptions()
+		options.Organization = "username"
+		options.Type = test.itemType
+		query, err := options.BuildQuey(test.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != test.query {
+			t.Fatalf("Expected query %q for type %d but got %q", test.query, test.itemType, query)
+		}
+	}
+}
+
+func TestBuildQueryTerms(t *testing.T) {
+	options := issues2markdown.NewQueryOptions()
+	options.Organization = "username"
+	options.Terms = []issues2markdown.Term{
+		issues2markdown.LabelQualifier("good first issue"),
+		issues2markdown.Not(issues2markdown.LabelQualifier("wontfix")),
+	}
+
+	tests := []struct {
+		q     string
+		query string
+	}{
+		{"", `type:issue label:"good first issue" -label:wontfix`},
+		{"repo:organization/repository", `type:issue repo:organization/repository label:"good first issue" -label:wontfix`},
+	}
+	for _, test := range tests {
+		query, err := options.BuildQuey(test.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != test.query {
+			t.Fatalf("Expected query %q but got %q", test.query, query)
+		}
+	}
+}
@@ -17,21 +17,194 @@
 
 package issues2markdown
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 const (
 	// DefaultIssueTemplate is the default template to render a list of issues in Markdown
 	DefaultIssueTemplate = `{{ range . }}- [{{ if eq .State "closed" }}x{{ else }} {{ end }}] {{ .GetOrganization }}/{{ .GetRepository }} : [#{{.Number}} {{ .Title }}]({{ .HTMLURL }})
 {{ end }}`
+
+	// DefaultIssueTreeTemplate is the default template to render a list of
+	// issues as a nested list that mirrors the tree of sub-issues
+	DefaultIssueTreeTemplate = `{{ range tree . }}{{ indent .Depth }}- [{{ if eq .State "closed" }}x{{ else }} {{ end }}] {{ .GetOrganization }}/{{ .GetRepository }} : [#{{.Number}} {{ .Title }}]({{ .HTMLURL }})` +
+		`{{ if .ParentOutside }} (sub-issue of {{ .Parent.Organization }}/{{ .Parent.Repository }}#{{ .Parent.Number }}){{ end }}
+{{ end }}`
 )
 
 // RenderOptions are the available options to modify the rendering of issues
+//
+// Besides the Issue fields, templates can use these functions:
+//
+//	age TIME                 the relative age of TIME, like "3 days ago"
+//	formatTime LAYOUT TIME   TIME formatted with LAYOUT in Location
+//	isStale DURATION TIME    whether TIME is older than DURATION, like "30d"
+//	reactions REACTIONS      a summary of the Issue Reactions, like "👍 12 ❤️ 3"
+//	tree ISSUES              the ISSUES as IssueTreeItems, children after parents
+//	indent DEPTH             two spaces per DEPTH level, to nest the list items
+//
+// TIME is a time.Time or a *time.Time, like the Issue CreatedAt, UpdatedAt
+// and ClosedAt fields.
 type RenderOptions struct {
 	TemplateSource string
+	// Location is the time zone of formatTime
+	Location *time.Location
+	// Now returns the current time for age and isStale, so the rendering can
+	// be deterministic
+	Now func() time.Time
 }
 
 // NewRenderOptions creates a new RenderOptions instance with sensible defaults
 func NewRenderOptions() *RenderOptions {
 	options := &RenderOptions{
 		TemplateSource: DefaultIssueTemplate,
+		Location:       time.Local,
+		Now:            time.Now,
 	}
 	return options
 }
+
+// funcs returns the template functions
+func (o *RenderOptions) funcs() map[string]interface{} {
+	now := o.Now
+	if now == nil {
+		now = time.Now
+	}
+	location := o.Location
+	if location == nil {
+		location = time.Local
+	}
+	return map[string]interface{}{
+		"age": func(value interface{}) (string, error) {
+			t, err := templateTime(value)
+			if err != nil || t.IsZero() {
+				return "", err
+			}
+			return relativeAge(t, now()), nil
+		},
+		"formatTime": func(layout string, value interface{}) (string, error) {
+			t, err := templateTime(value)
+			if err != nil || t.IsZero() {
+				return "", err
+			}
+			return t.In(location).Format(layout), nil
+		},
+		"isStale": func(duration string, value interface{}) (bool, error) {
+			d, err := parseStaleDuration(duration)
+			if err != nil {
+				return false, err
+			}
+			t, err := templateTime(value)
+			if err != nil || t.IsZero() {
+				return false, err
+			}
+			return now().Sub(t) > d, nil
+		},
+		"reactions": reactionsSummary,
+		"tree":      issueTree,
+		"indent": func(depth int) string {
+			return strings.Repeat("  ", depth)
+		},
+	}
+}
+
+// reactionsSummary returns the emojis of the reactions with their counts,
+// without the reactions nobody used
+func reactionsSummary(reactions Reactions) string {
+	counts := []struct {
+		emoji string
+		count int
+	}{
+		{"👍", reactions.PlusOne},
+		{"👎", reactions.MinusOne},
+		{"😄", reactions.Laugh},
+		{"🎉", reactions.Hooray},
+		{"😕", reactions.Confused},
+		{"❤️", reactions.Heart},
+		{"🚀", reactions.Rocket},
+		{"👀", reactions.Eyes},
+	}
+	var summary []string
+	for _, c := range counts {
+		if c.count > 0 {
+			summary = append(summary, c.emoji+" "+strconv.Itoa(c.count))
+		}
+	}
+	return strings.Join(summary, " ")
+}
+
+// templateTime returns the time of a template argument, the zero time for
+// nil
+func templateTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, nil
+		}
+		return *v, nil
+	case nil:
+		return time.Time{}, nil
+	}
+	return time.Time{}, fmt.Errorf("%v is not a time", value)
+}
+
+// relativeAge returns how long ago, or in how long, t is from now, in the
+// largest unit
+func relativeAge(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+	format := "%s ago"
+	if d < 0 {
+		d = -d
+		format = "in %s"
+	}
+	day := 24 * time.Hour
+	var amount string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		amount = plural(int(d/time.Minute), "minute")
+	case d < day:
+		amount = plural(int(d/time.Hour), "hour")
+	case d < 30*day:
+		amount = plural(int(d/day), "day")
+	case d < 365*day:
+		amount = plural(int(d/(30*day)), "month")
+	default:
+		amount = plural(int(d/(365*day)), "year")
+	}
+	return fmt.Sprintf(format, amount)
+}
+
+// plural returns the count of a unit, like "1 day" or "3 days"
+func plural(count int, unit string) string {
+	if count == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// parseStaleDuration parses a duration that, besides the time.ParseDuration
+// units, can be a number of days, like "30d", or weeks, like "2w"
+func parseStaleDuration(value string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if strings.HasSuffix(value, suffix) {
+			count, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return time.ParseDuration(value)
+}
@@ -19,6 +19,7 @@ package issues2markdown_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/issues2markdown/issues2markdown"
 )
@@ -30,3 +31,126 @@ func TestInstanceRenderOptions(t *testing.T) {
 		t.Fatalf("Default RenderOptions template source expected to be %q but got %q", expectedTemplateSource, options.TemplateSource)
 	}
 }
+
+func TestRenderTimeHelpers(t *testing.T) {
+	now := time.Date(2018, 10, 9, 12, 0, 0, 0, time.UTC)
+	closedAt := now.Add(-90 * time.Minute)
+	issues := []issues2markdown.Issue{
+		{Number: 1, CreatedAt: now.Add(-3 * 24 * time.Hour), UpdatedAt: now.Add(-40 * 24 * time.Hour), ClosedAt: &closedAt},
+		{Number: 2, CreatedAt: now.Add(-400 * 24 * time.Hour), UpdatedAt: now.Add(-30 * time.Second)},
+		{Number: 3, CreatedAt: now.Add(-24 * time.Hour), UpdatedAt: now.Add(2 * time.Hour)},
+	}
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(&fakeProvider{user: &issues2markdown.User{Login: "username"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewRenderOptions()
+	options.Now = func() time.Time { return now }
+	options.Location = time.FixedZone("CEST", 2*60*60)
+	options.TemplateSource = `{{ range . }}- #{{ .Number }} opened {{ age .CreatedAt }} on {{ .CreatedAt | formatTime "2006-01-02 15:04 MST" }}, updated {{ age .UpdatedAt }}` +
+		`{{ with .ClosedAt }}, closed {{ age . }}{{ end }}{{ if isStale "30d" .UpdatedAt }} (stale){{ end }}
+{{ end }}`
+	markdown, err := i2md.Render(issues, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMarkdown := `- #1 opened 3 days ago on 2018-10-06 14:00 CEST, updated 1 month ago, closed 1 hour ago (stale)
+- #2 opened 1 year ago on 2017-09-04 14:00 CEST, updated just now
+- #3 opened 1 day ago on 2018-10-08 14:00 CEST, updated in 2 hours`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestRenderTimeHelpersWithoutTime(t *testing.T) {
+	i2md, err := issues2markdown.NewIssuesToMarkdown(&fakeProvider{user: &issues2markdown.User{Login: "username"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewRenderOptions()
+	options.TemplateSource = `{{ range . }}#{{ .Number }}[{{ age .CreatedAt }}][{{ formatTime "2006" .ClosedAt }}][{{ isStale "2w" .UpdatedAt }}]{{ end }}`
+	markdown, err := i2md.Render([]issues2markdown.Issue{{Number: 1}}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expectedMarkdown := "#1[][][false]"; markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	i2md, err := issues2markdown.NewIssuesToMarkdown(&fakeProvider{user: &issues2markdown.User{Login: "username"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []string{
+		`{{ range . }}#{{ .Number }}{{ if isStale "30x" .UpdatedAt }} (stale){{ end }}{{ end }}`,
+		`{{ range . }}#{{ .Number }} {{ age .Title }}{{ end }}`,
+		`{{ range . }}#{{ .Number }}`,
+	}
+	for _, source := range tests {
+		options := issues2markdown.NewRenderOptions()
+		options.TemplateSource = source
+		if _, err := i2md.Render([]issues2markdown.Issue{{Number: 1}}, options); err == nil {
+			t.Fatalf("Expected an error for the template %q", source)
+		}
+	}
+}
+
+func TestRenderPullRequests(t *testing.T) {
+	issues := []issues2markdown.Issue{
+		{Number: 1, Title: "Merged", State: "closed", IsPullRequest: true, Merged: true},
+		{Number: 2, Title: "Closed", State: "closed", IsPullRequest: true},
+		{Number: 3, Title: "Draft", State: "open", IsPullRequest: true, Draft: true},
+		{Number: 4, Title: "Approved", State: "open", IsPullRequest: true, ReviewState: issues2markdown.ReviewApproved},
+	}
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(&fakeProvider{user: &issues2markdown.User{Login: "username"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewRenderOptions()
+	options.TemplateSource = `{{ range . }}- [{{ if .Merged }}x{{ else }} {{ end }}] #{{ .Number }} {{ if .Draft }}_{{ .Title }}_{{ else }}{{ .Title }}{{ end }}{{ with .ReviewState }} ({{ . }}){{ end }}
+{{ end }}`
+	markdown, err := i2md.Render(issues, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMarkdown := `- [x] #1 Merged
+- [ ] #2 Closed
+- [ ] #3 _Draft_
+- [ ] #4 Approved (approved)`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
+
+func TestRenderReactions(t *testing.T) {
+	issues := []issues2markdown.Issue{
+		{Number: 1, Reactions: issues2markdown.Reactions{TotalCount: 15, PlusOne: 12, Heart: 3}},
+		{Number: 2, Reactions: issues2markdown.Reactions{TotalCount: 3, MinusOne: 1, Rocket: 1, Eyes: 1}},
+		{Number: 3},
+	}
+
+	i2md, err := issues2markdown.NewIssuesToMarkdown(&fakeProvider{user: &issues2markdown.User{Login: "username"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewRenderOptions()
+	options.TemplateSource = `{{ range . }}- #{{ .Number }} [{{ reactions .Reactions }}]
+{{ end }}`
+	markdown, err := i2md.Render(issues, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMarkdown := `- #1 [👍 12 ❤️ 3]
+- #2 [👎 1 🚀 1 👀 1]
+- #3 []`
+	if markdown != expectedMarkdown {
+		t.Fatalf("Expected %q but got %q", expectedMarkdown, markdown)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+// Package replay records the HTTP exchanges of the issue providers to a
+// cassette file and serves them back, so the result of querying and rendering
+// issues can be tested without hitting the provider API.
+//
+// A Recorder is an http.RoundTripper. In ModeRecord it sends the requests
+// through the underlying transport and saves the exchanges to the cassette
+// when stopped. In ModeReplay it answers the requests with the recorded
+// responses.
+//
+//	recorder, err := replay.New("testdata/query.json", replay.ModeReplay, nil)
+//	...
+//	defer recorder.Stop()
+//	provider := issues2markdown.NewGithubProvider(github.NewClient(recorder.Client()))
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Mode is the operation mode of a Recorder
+type Mode int
+
+const (
+	// ModeReplay serves the recorded exchanges
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests and records the exchanges
+	ModeRecord
+)
+
+// Cassette is the list of HTTP exchanges stored in a cassette file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded HTTP exchange
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded HTTP request. Request headers are not recorded so
+// credentials don't end up in the cassette files.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that records or replays HTTP exchanges
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	replayed []bool
+}
+
+// New creates a Recorder instance for the cassette file at path.
+//
+// In ModeReplay the cassette file must exist. In ModeRecord the requests are
+// sent through transport, http.DefaultTransport if nil, and the cassette file
+// is overwritten by Stop.
+func New(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	recorder := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: transport,
+		cassette:  &Cassette{},
+	}
+	if mode == ModeReplay {
+		cassette, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		recorder.cassette = cassette
+		recorder.replayed = make([]bool, len(cassette.Interactions))
+	}
+	return recorder, nil
+}
+
+// Load reads the cassette file at path
+func Load(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := &Cassette{}
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette file at path
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Client returns an http.Client that uses the Recorder as transport
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{
+		Transport: r,
+	}
+}
+
+// Stop saves the recorded exchanges to the cassette file in ModeRecord
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// RoundTrip records or replays a single HTTP exchange
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	request := Request{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Body:   body,
+	}
+	if r.mode == ModeRecord {
+		return r.record(req, request)
+	}
+	return r.replay(req, request)
+}
+
+// record sends the request and records the exchange
+func (r *Recorder) record(req *http.Request, request Request) (*http.Response, error) {
+	response, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	header := response.Header.Clone()
+	header.Del("Set-Cookie")
+	interaction := Interaction{
+		Request: request,
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Header:     header,
+			Body:       string(data),
+		},
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return response, nil
+}
+
+// replay answers the request with the first recorded exchange not replayed
+// yet that matches it, or with the last matching exchange if all of them
+// were already replayed
+func (r *Recorder) replay(req *http.Request, request Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !matches(interaction.Request, request) {
+			continue
+		}
+		found = i
+		if !r.replayed[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("replay: no recorded interaction for %s %s in %s", request.Method, request.URL, r.path)
+	}
+	r.replayed[found] = true
+
+	recorded := r.cassette.Interactions[found].Response
+	response := &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}
+	if response.Header == nil {
+		response.Header = http.Header{}
+	}
+	return response, nil
+}
+
+// matches reports whether a recorded request matches a request
+//
+// The query parameters are compared regardless of their order and encoding.
+func matches(recorded Request, request Request) bool {
+	if recorded.Method != request.Method || recorded.Body != request.Body {
+		return false
+	}
+	if recorded.URL == request.URL {
+		return true
+	}
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	requestURL, err := url.Parse(request.URL)
+	if err != nil {
+		return false
+	}
+	if recordedURL.Scheme != requestURL.Scheme ||
+		recordedURL.Host != requestURL.Host ||
+		recordedURL.Path != requestURL.Path {
+		return false
+	}
+	return reflect.DeepEqual(recordedURL.Query(), requestURL.Query())
+}
+
+// readRequestBody reads the request body and restores it to be sent
+func readRequestBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
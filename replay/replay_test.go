@@ -0,0 +1,137 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package replay_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/issues2markdown/issues2markdown/replay"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "token secret_token")
+	response, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, string(body)
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			http.Error(w, "Not Found", 404)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path": %q, "request": %d}`, r.URL.Path, requests)
+	}))
+
+	// record
+	recorder, err := replay.New(path, replay.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := recorder.Client()
+	get(t, client, server.URL+"/user")
+	get(t, client, server.URL+"/search/issues?q=is%3Aopen")
+	get(t, client, server.URL+"/search/issues?q=is%3Aopen")
+	get(t, client, server.URL+"/missing")
+	if err := recorder.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret_token") {
+		t.Fatalf("Expected credentials not to be recorded but got %s", data)
+	}
+
+	// replay
+	recorder, err = replay.New(path, replay.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := recorder.Stop(); err != nil {
+			t.Error(err)
+		}
+	}()
+	client = recorder.Client()
+
+	tests := []struct {
+		url        string
+		statusCode int
+		body       string
+	}{
+		{"/user", 200, `{"path": "/user", "request": 1}`},
+		{"/search/issues?q=is%3Aopen", 200, `{"path": "/search/issues", "request": 2}`},
+		{"/search/issues?q=is%3Aopen", 200, `{"path": "/search/issues", "request": 3}`},
+		{"/search/issues?q=is%3Aopen", 200, `{"path": "/search/issues", "request": 3}`},
+		{"/missing", 404, "Not Found\n"},
+	}
+	for _, test := range tests {
+		statusCode, body := get(t, client, server.URL+test.url)
+		if statusCode != test.statusCode {
+			t.Fatalf("Expected status code %d for %s but got %d", test.statusCode, test.url, statusCode)
+		}
+		if body != test.body {
+			t.Fatalf("Expected body %q for %s but got %q", test.body, test.url, body)
+		}
+	}
+
+	_, err = client.Get(server.URL + "/unknown")
+	if err == nil {
+		t.Fatal("Expected an error for a request not recorded")
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := replay.New("testdata/missing.json", replay.ModeReplay, nil)
+	if err == nil {
+		t.Fatal("Expected an error for a missing cassette")
+	}
+}
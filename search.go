@@ -0,0 +1,267 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// searchDateLayout is the layout of the dates of the search qualifiers
+const searchDateLayout = "2006-01-02"
+
+// Term is a term of a search query
+type Term interface {
+	// String returns the term in the Github search syntax
+	String() string
+}
+
+// SearchQuery is a search query built from typed terms, which are all
+// required to match
+type SearchQuery struct {
+	Terms []Term
+}
+
+// NewSearchQuery creates a SearchQuery instance with the terms
+func NewSearchQuery(terms ...Term) *SearchQuery {
+	query := &SearchQuery{
+		Terms: terms,
+	}
+	return query
+}
+
+// Add appends the terms to the query
+func (sq *SearchQuery) Add(terms ...Term) *SearchQuery {
+	sq.Terms = append(sq.Terms, terms...)
+	return sq
+}
+
+// String returns the query in the Github search syntax. The empty terms are
+// skipped.
+func (sq *SearchQuery) String() string {
+	var terms []string
+	for _, term := range sq.Terms {
+		if s := term.String(); s != "" {
+			terms = append(terms, s)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// Qualifier is a key:value term, like label:bug. Negated qualifiers exclude
+// the matching issues.
+type Qualifier struct {
+	Key     string
+	Value   string
+	Negated bool
+}
+
+// String returns the qualifier with its value quoted if needed
+func (q Qualifier) String() string {
+	s := q.Key + ":" + quoteSearchValue(q.Value)
+	if q.Negated {
+		s = "-" + s
+	}
+	return s
+}
+
+// Text is a free text term, quoted if it has spaces to match the exact
+// phrase
+type Text string
+
+// String returns the quoted text
+func (t Text) String() string {
+	return quoteSearchValue(string(t))
+}
+
+// ExcludedText excludes the issues with the text, with the NOT keyword
+type ExcludedText string
+
+// String returns the quoted text after NOT
+func (t ExcludedText) String() string {
+	return "NOT " + quoteSearchValue(string(t))
+}
+
+// Raw is a term in the search syntax used as is, like the queries of the
+// command line
+type Raw string
+
+// String returns the raw term
+func (r Raw) String() string {
+	return strings.TrimSpace(string(r))
+}
+
+// OrTerm matches the issues that match any of its terms
+type OrTerm []Term
+
+// String returns the terms joined by OR, in parentheses if there are
+// several
+func (o OrTerm) String() string {
+	var terms []string
+	for _, term := range o {
+		if s := term.String(); s != "" {
+			terms = append(terms, s)
+		}
+	}
+	if len(terms) < 2 {
+		return strings.Join(terms, "")
+	}
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
+
+// AndTerm matches the issues that match all its terms, to group terms
+// inside an OrTerm
+type AndTerm []Term
+
+// String returns the terms joined by spaces, in parentheses if there are
+// several
+func (a AndTerm) String() string {
+	s := NewSearchQuery(a...).String()
+	if len(a) < 2 {
+		return s
+	}
+	return "(" + s + ")"
+}
+
+// Or creates a term that matches any of the terms
+func Or(terms ...Term) OrTerm {
+	return OrTerm(terms)
+}
+
+// Not negates a qualifier
+func Not(q Qualifier) Qualifier {
+	q.Negated = !q.Negated
+	return q
+}
+
+// IsQualifier creates an is: qualifier, like is:open or is:merged
+func IsQualifier(value string) Qualifier {
+	return Qualifier{Key: "is", Value: value}
+}
+
+// StateQualifier creates a state: qualifier, open or closed
+func StateQualifier(state string) Qualifier {
+	return Qualifier{Key: "state", Value: state}
+}
+
+// ItemTypeQualifier creates the type: qualifier of the item type, which is
+// empty for all the types
+func ItemTypeQualifier(itemType ItemType) Term {
+	switch itemType {
+	case ItemTypeIssues:
+		return Qualifier{Key: "type", Value: "issue"}
+	case ItemTypePullRequests:
+		return Qualifier{Key: "type", Value: "pr"}
+	}
+	return Raw("")
+}
+
+// RepoQualifier creates a repo: qualifier of the repository of the
+// organization
+func RepoQualifier(organization string, repository string) Qualifier {
+	return Qualifier{Key: "repo", Value: organization + "/" + repository}
+}
+
+// OrgQualifier creates an org: qualifier
+func OrgQualifier(organization string) Qualifier {
+	return Qualifier{Key: "org", Value: organization}
+}
+
+// UserQualifier creates a user: qualifier of the repositories of the user
+func UserQualifier(login string) Qualifier {
+	return Qualifier{Key: "user", Value: login}
+}
+
+// LabelQualifier creates a label: qualifier
+func LabelQualifier(name string) Qualifier {
+	return Qualifier{Key: "label", Value: name}
+}
+
+// MilestoneQualifier creates a milestone: qualifier
+func MilestoneQualifier(title string) Qualifier {
+	return Qualifier{Key: "milestone", Value: title}
+}
+
+// AssigneeQualifier creates an assignee: qualifier
+func AssigneeQualifier(login string) Qualifier {
+	return Qualifier{Key: "assignee", Value: login}
+}
+
+// AuthorQualifier creates an author: qualifier
+func AuthorQualifier(login string) Qualifier {
+	return Qualifier{Key: "author", Value: login}
+}
+
+// MentionsQualifier creates a mentions: qualifier
+func MentionsQualifier(login string) Qualifier {
+	return Qualifier{Key: "mentions", Value: login}
+}
+
+// ArchivedQualifier creates an archived: qualifier
+func ArchivedQualifier(archived bool) Qualifier {
+	return Qualifier{Key: "archived", Value: strconv.FormatBool(archived)}
+}
+
+// CreatedQualifier creates a created: qualifier of the dates between from
+// and to, both included. A zero time leaves its side of the range open.
+func CreatedQualifier(from time.Time, to time.Time) Qualifier {
+	return dateQualifier("created", from, to)
+}
+
+// UpdatedQualifier creates an updated: qualifier of the dates between from
+// and to, both included. A zero time leaves its side of the range open.
+func UpdatedQualifier(from time.Time, to time.Time) Qualifier {
+	return dateQualifier("updated", from, to)
+}
+
+// ClosedQualifier creates a closed: qualifier of the dates between from and
+// to, both included. A zero time leaves its side of the range open.
+func ClosedQualifier(from time.Time, to time.Time) Qualifier {
+	return dateQualifier("closed", from, to)
+}
+
+// dateQualifier creates a qualifier of a date range: FROM..TO, >=FROM or
+// <=TO, any date if both are zero
+func dateQualifier(key string, from time.Time, to time.Time) Qualifier {
+	value := ""
+	switch {
+	case !from.IsZero() && !to.IsZero():
+		value = from.Format(searchDateLayout) + ".." + to.Format(searchDateLayout)
+	case !from.IsZero():
+		value = ">=" + from.Format(searchDateLayout)
+	case !to.IsZero():
+		value = "<=" + to.Format(searchDateLayout)
+	default:
+		value = "*..*"
+	}
+	return Qualifier{Key: key, Value: value}
+}
+
+// quoteSearchValue quotes the values with spaces or parentheses, the quotes
+// inside values can't be escaped in the search syntax and are removed
+func quoteSearchValue(value string) string {
+	value = strings.Replace(value, "\"", "", -1)
+	if value == "" || strings.IndexFunc(value, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '(' || r == ')'
+	}) != -1 {
+		return "\"" + value + "\""
+	}
+	return value
+}
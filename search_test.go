@@ -0,0 +1,131 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+func testTerm(t *testing.T, term issues2markdown.Term, expected string) {
+	t.Helper()
+	if got := term.String(); got != expected {
+		t.Fatalf("Expected term %q but got %q", expected, got)
+	}
+}
+
+func TestIsQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.IsQualifier("open"), "is:open")
+}
+
+func TestStateQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.StateQualifier("closed"), "state:closed")
+}
+
+func TestItemTypeQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.ItemTypeQualifier(issues2markdown.ItemTypeIssues), "type:issue")
+	testTerm(t, issues2markdown.ItemTypeQualifier(issues2markdown.ItemTypePullRequests), "type:pr")
+	testTerm(t, issues2markdown.ItemTypeQualifier(issues2markdown.ItemTypeAll), "")
+}
+
+func TestRepoQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.RepoQualifier("organization", "repository"), "repo:organization/repository")
+}
+
+func TestOrgQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.OrgQualifier("organization"), "org:organization")
+}
+
+func TestUserQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.UserQualifier("username"), "user:username")
+}
+
+func TestLabelQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.LabelQualifier("bug"), "label:bug")
+	testTerm(t, issues2markdown.LabelQualifier("good first issue"), `label:"good first issue"`)
+	testTerm(t, issues2markdown.LabelQualifier("size (L)"), `label:"size (L)"`)
+	testTerm(t, issues2markdown.LabelQualifier(`say "hi"`), `label:"say hi"`)
+}
+
+func TestMilestoneQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.MilestoneQualifier("v1.0"), "milestone:v1.0")
+	testTerm(t, issues2markdown.MilestoneQualifier("Sprint 4"), `milestone:"Sprint 4"`)
+}
+
+func TestAssigneeQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.AssigneeQualifier("octocat"), "assignee:octocat")
+}
+
+func TestAuthorQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.AuthorQualifier("username"), "author:username")
+}
+
+func TestMentionsQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.MentionsQualifier("hubot"), "mentions:hubot")
+}
+
+func TestArchivedQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.ArchivedQualifier(false), "archived:false")
+	testTerm(t, issues2markdown.ArchivedQualifier(true), "archived:true")
+}
+
+func TestDateQualifiers(t *testing.T) {
+	from := time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2018, 10, 9, 23, 59, 0, 0, time.UTC)
+	testTerm(t, issues2markdown.CreatedQualifier(from, to), "created:2018-10-01..2018-10-09")
+	testTerm(t, issues2markdown.CreatedQualifier(from, time.Time{}), "created:>=2018-10-01")
+	testTerm(t, issues2markdown.UpdatedQualifier(time.Time{}, to), "updated:<=2018-10-09")
+	testTerm(t, issues2markdown.ClosedQualifier(from, to), "closed:2018-10-01..2018-10-09")
+	testTerm(t, issues2markdown.ClosedQualifier(time.Time{}, time.Time{}), "closed:*..*")
+}
+
+func TestNotQualifier(t *testing.T) {
+	testTerm(t, issues2markdown.Not(issues2markdown.LabelQualifier("wontfix")), "-label:wontfix")
+	testTerm(t, issues2markdown.Not(issues2markdown.LabelQualifier("won't fix yet")), `-label:"won't fix yet"`)
+	testTerm(t, issues2markdown.Not(issues2markdown.Not(issues2markdown.AuthorQualifier("username"))), "author:username")
+}
+
+func TestOrTerm(t *testing.T) {
+	testTerm(t, issues2markdown.Or(issues2markdown.LabelQualifier("bug"), issues2markdown.LabelQualifier("help wanted")), `(label:bug OR label:"help wanted")`)
+	testTerm(t, issues2markdown.Or(issues2markdown.AuthorQualifier("username")), "author:username")
+	testTerm(t, issues2markdown.Or(), "")
+}
+
+func TestTextTerm(t *testing.T) {
+	testTerm(t, issues2markdown.Text("crash"), "crash")
+	testTerm(t, issues2markdown.Text("out of memory"), `"out of memory"`)
+}
+
+func TestSearchQuery(t *testing.T) {
+	query := issues2markdown.NewSearchQuery(
+		issues2markdown.ItemTypeQualifier(issues2markdown.ItemTypeAll),
+		issues2markdown.IsQualifier("open"),
+		issues2markdown.RepoQualifier("organization", "repository"),
+	)
+	query.Add(
+		issues2markdown.Or(issues2markdown.LabelQualifier("bug"), issues2markdown.LabelQualifier("good first issue")),
+		issues2markdown.Not(issues2markdown.AssigneeQualifier("octocat")),
+		issues2markdown.Text("out of memory"),
+	)
+	expectedQuery := `is:open repo:organization/repository (label:bug OR label:"good first issue") -assignee:octocat "out of memory"`
+	if got := query.String(); got != expectedQuery {
+		t.Fatalf("Expected query %q but got %q", expectedQuery, got)
+	}
+}
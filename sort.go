@@ -0,0 +1,59 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"fmt"
+	"sort"
+)
+
+// the values of the issues to sort by, with the Github sort names
+var sortKeys = map[string]func(issue *Issue) int64{
+	"comments":                func(issue *Issue) int64 { return int64(issue.CommentsCount) },
+	"reactions":               func(issue *Issue) int64 { return int64(issue.Reactions.TotalCount) },
+	"reactions-+1":            func(issue *Issue) int64 { return int64(issue.Reactions.PlusOne) },
+	"reactions--1":            func(issue *Issue) int64 { return int64(issue.Reactions.MinusOne) },
+	"reactions-smile":         func(issue *Issue) int64 { return int64(issue.Reactions.Laugh) },
+	"reactions-tada":          func(issue *Issue) int64 { return int64(issue.Reactions.Hooray) },
+	"reactions-thinking_face": func(issue *Issue) int64 { return int64(issue.Reactions.Confused) },
+	"reactions-heart":         func(issue *Issue) int64 { return int64(issue.Reactions.Heart) },
+	"reactions-rocket":        func(issue *Issue) int64 { return int64(issue.Reactions.Rocket) },
+	"reactions-eyes":          func(issue *Issue) int64 { return int64(issue.Reactions.Eyes) },
+	"created":                 func(issue *Issue) int64 { return issue.CreatedAt.UnixNano() },
+	"updated":                 func(issue *Issue) int64 { return issue.UpdatedAt.UnixNano() },
+}
+
+// SortIssues sorts the issues in place by key, one of the Github sort names
+// comments, reactions, reactions-+1, reactions--1, reactions-smile,
+// reactions-tada, reactions-thinking_face, reactions-heart,
+// reactions-rocket, reactions-eyes, created and updated.
+//
+// The sort is stable, so the issues with the same value keep their order.
+func SortIssues(issues []Issue, key string, descending bool) error {
+	value, ok := sortKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown sort key %q", key)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if descending {
+			return value(&issues[i]) > value(&issues[j])
+		}
+		return value(&issues[i]) < value(&issues[j])
+	})
+	return nil
+}
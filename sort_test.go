@@ -0,0 +1,74 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+func sortedNumbers(issues []issues2markdown.Issue) []int {
+	var numbers []int
+	for _, issue := range issues {
+		numbers = append(numbers, issue.Number)
+	}
+	return numbers
+}
+
+func TestSortIssues(t *testing.T) {
+	now := time.Date(2018, 10, 9, 12, 0, 0, 0, time.UTC)
+	newIssues := func() []issues2markdown.Issue {
+		return []issues2markdown.Issue{
+			{Number: 1, CommentsCount: 2, Reactions: issues2markdown.Reactions{TotalCount: 1, PlusOne: 1}, CreatedAt: now.Add(-time.Hour)},
+			{Number: 2, CommentsCount: 5, Reactions: issues2markdown.Reactions{TotalCount: 4, Heart: 4}, CreatedAt: now.Add(-3 * time.Hour)},
+			{Number: 3, CommentsCount: 2, Reactions: issues2markdown.Reactions{TotalCount: 3, PlusOne: 3}, CreatedAt: now.Add(-2 * time.Hour)},
+		}
+	}
+	tests := []struct {
+		key        string
+		descending bool
+		expected   []int
+	}{
+		{"comments", true, []int{2, 1, 3}},
+		{"comments", false, []int{1, 3, 2}},
+		{"reactions", true, []int{2, 3, 1}},
+		{"reactions-+1", true, []int{3, 1, 2}},
+		{"reactions-heart", true, []int{2, 1, 3}},
+		{"created", false, []int{2, 3, 1}},
+	}
+	for _, test := range tests {
+		issues := newIssues()
+		if err := issues2markdown.SortIssues(issues, test.key, test.descending); err != nil {
+			t.Fatal(err)
+		}
+		got := sortedNumbers(issues)
+		for i := range test.expected {
+			if got[i] != test.expected[i] {
+				t.Fatalf("Expected %s descending %v to sort %v but got %v", test.key, test.descending, test.expected, got)
+			}
+		}
+	}
+}
+
+func TestSortIssuesUnknownKey(t *testing.T) {
+	if err := issues2markdown.SortIssues(nil, "votes", true); err == nil {
+		t.Fatal("Expected an error for an unknown sort key")
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// searchSplitLayout is the layout of the created times of the slices of
+	// a split query
+	searchSplitLayout = "2006-01-02T15:04:05Z"
+)
+
+// searchSplitStart is the created time the slices of a split query without
+// created lower bound are bisected from, before the first Github issues. The
+// earliest slice is open, for the issues created before it.
+var searchSplitStart = time.Date(2007, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// IncompleteResultsError reports a search that didn't retrieve all its
+// results, even split in the smallest slice of created time
+type IncompleteResultsError struct {
+	// Query is the query of the slice with too many results
+	Query string
+	// Total is the number of results of the slice, if the provider counts
+	// them
+	Total int
+	// Retrieved is the number of results retrieved for the slice
+	Retrieved int
+}
+
+// Error returns the query with incomplete results
+func (e *IncompleteResultsError) Error() string {
+	return fmt.Sprintf("incomplete results for query %q: %d of %d retrieved", e.Query, e.Retrieved, e.Total)
+}
+
+// newIncompleteResultsError creates an IncompleteResultsError of the
+// results of a query
+func newIncompleteResultsError(query string, first *SearchResult, issues []Issue) *IncompleteResultsError {
+	err := &IncompleteResultsError{
+		Query:     query,
+		Total:     first.Total,
+		Retrieved: len(issues),
+	}
+	return err
+}
+
+// searchExceeds returns whether the results of a search are above the limit
+// or incomplete
+func searchExceeds(result *SearchResult, limit int) bool {
+	return result.Incomplete || (limit > 0 && result.Total > limit)
+}
+
+// searchSplitter searches a query in slices of created time
+type searchSplitter struct {
+	provider IssueProvider
+	options  SearchOptions
+	limit    int
+	// terms are the terms of the query, without the created qualifiers
+	terms []Term
+	// from and to are the created time range of the query, to excluded
+	from time.Time
+	to   time.Time
+	// openFrom is whether the query has no created lower bound, the slices
+	// from the from time include the issues created before it
+	openFrom bool
+}
+
+// newSearchSplitter creates a searchSplitter of the query. The range of
+// created time is the one of the created qualifiers of the query, if any.
+// It returns false if the query can't be split.
+func newSearchSplitter(provider IssueProvider, query string, options SearchOptions, limit int) (*searchSplitter, bool) {
+	parsed, err := ParseSearchQuery(query)
+	if err != nil {
+		return nil, false
+	}
+	splitter := &searchSplitter{
+		provider: provider,
+		options:  options,
+		limit:    limit,
+		to:       time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second),
+	}
+	for _, term := range parsed.Terms {
+		q, ok := term.(Qualifier)
+		if !ok || q.Key != "created" || q.Negated {
+			splitter.terms = append(splitter.terms, term)
+			continue
+		}
+		from, to, ok := parseCreatedRange(q.Value)
+		if !ok {
+			return nil, false
+		}
+		if from.After(splitter.from) {
+			splitter.from = from
+		}
+		if !to.IsZero() && to.Before(splitter.to) {
+			splitter.to = to
+		}
+	}
+	if splitter.from.IsZero() {
+		splitter.from = searchSplitStart
+		splitter.openFrom = true
+	}
+	return splitter, true
+}
+
+// search retrieves the results of all the slices, without duplicates
+func (s *searchSplitter) search(ctx context.Context) ([]Issue, error) {
+	issues, err := s.searchRange(ctx, s.from, s.to)
+	if err != nil && issues == nil {
+		return nil, err
+	}
+	return uniqueIssues(issues), err
+}
+
+// searchRange retrieves the results created between from and to, to
+// excluded, bisecting the range while it has too many results
+func (s *searchSplitter) searchRange(ctx context.Context, from time.Time, to time.Time) ([]Issue, error) {
+	if !to.After(from) {
+		return nil, nil
+	}
+	lower := from.Format(searchSplitLayout)
+	if s.openFrom && from.Equal(s.from) {
+		lower = "*"
+	}
+	created := Qualifier{
+		Key:   "created",
+		Value: lower + ".." + to.Add(-time.Second).Format(searchSplitLayout),
+	}
+	query := NewSearchQuery(s.terms...).Add(created).String()
+	options := s.options
+	first, err := s.provider.Search(ctx, query, &options)
+	if err != nil {
+		return nil, err
+	}
+	if searchExceeds(first, s.limit) && to.Sub(from) > time.Second {
+		middle := from.Add(to.Sub(from) / 2).Truncate(time.Second)
+		older, err := s.searchRange(ctx, from, middle)
+		if _, ok := err.(*IncompleteResultsError); err != nil && !ok {
+			return nil, err
+		}
+		newer, newerErr := s.searchRange(ctx, middle, to)
+		if _, ok := newerErr.(*IncompleteResultsError); newerErr != nil && !ok {
+			return nil, newerErr
+		}
+		if err == nil {
+			err = newerErr
+		}
+		return append(older, newer...), err
+	}
+	issues, err := searchPages(ctx, s.provider, query, options, first)
+	if err != nil {
+		return nil, err
+	}
+	if searchExceeds(first, s.limit) {
+		return issues, newIncompleteResultsError(query, first, issues)
+	}
+	return issues, nil
+}
+
+// parseCreatedRange returns the range of a created qualifier value, to
+// excluded. The zero to is an open range.
+func parseCreatedRange(value string) (time.Time, time.Time, bool) {
+	// parseBound returns the time of a date or date time, and the time
+	// after it
+	parseBound := func(v string) (time.Time, time.Time, bool) {
+		if t, err := time.Parse(searchDateLayout, v); err == nil {
+			return t, t.Add(24 * time.Hour), true
+		}
+		for _, layout := range searchDateLayouts[1:] {
+			if t, err := time.Parse(layout, v); err == nil {
+				t = t.UTC()
+				return t, t.Add(time.Second), true
+			}
+		}
+		return time.Time{}, time.Time{}, false
+	}
+
+	var from, to time.Time
+	switch {
+	case strings.Contains(value, ".."):
+		bounds := strings.SplitN(value, "..", 2)
+		if bounds[0] != "*" {
+			t, _, ok := parseBound(bounds[0])
+			if !ok {
+				return from, to, false
+			}
+			from = t
+		}
+		if bounds[1] != "*" {
+			_, after, ok := parseBound(bounds[1])
+			if !ok {
+				return from, to, false
+			}
+			to = after
+		}
+	case strings.HasPrefix(value, ">="):
+		t, _, ok := parseBound(value[2:])
+		if !ok {
+			return from, to, false
+		}
+		from = t
+	case strings.HasPrefix(value, ">"):
+		_, after, ok := parseBound(value[1:])
+		if !ok {
+			return from, to, false
+		}
+		from = after
+	case strings.HasPrefix(value, "<="):
+		_, after, ok := parseBound(value[2:])
+		if !ok {
+			return from, to, false
+		}
+		to = after
+	case strings.HasPrefix(value, "<"):
+		t, _, ok := parseBound(value[1:])
+		if !ok {
+			return from, to, false
+		}
+		to = t
+	default:
+		t, after, ok := parseBound(value)
+		if !ok {
+			return from, to, false
+		}
+		from, to = t, after
+	}
+	return from, to, true
+}
+
+// uniqueIssues returns the issues without the duplicates of the same
+// repository and number, like the ones that moved between pages
+func uniqueIssues(issues []Issue) []Issue {
+	var result []Issue
+	seen := map[string]bool{}
+	for i := range issues {
+		organization, repository, err := issueRepository(&issues[i])
+		if err == nil {
+			key := issueKey(organization, repository, issues[i].Number)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		result = append(result, issues[i])
+	}
+	return result
+}
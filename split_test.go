@@ -0,0 +1,216 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// fakeLimitProvider serves its issues filtered by the created range of the
+// query, with pages of pageSize issues and at most limit results per query,
+// like the Github search
+type fakeLimitProvider struct {
+	fakeProvider
+	limit    int
+	pageSize int
+}
+
+func (fp *fakeLimitProvider) SearchLimit() int {
+	return fp.limit
+}
+
+func (fp *fakeLimitProvider) Search(ctx context.Context, query string, options *issues2markdown.SearchOptions) (*issues2markdown.SearchResult, error) {
+	fp.queries = append(fp.queries, query)
+	from, to := time.Time{}, time.Now().Add(24*time.Hour)
+	for _, term := range strings.Fields(query) {
+		if !strings.HasPrefix(term, "created:") {
+			continue
+		}
+		bounds := strings.SplitN(strings.TrimPrefix(term, "created:"), "..", 2)
+		var err error
+		if from, err = parseFakeCreated(bounds[0], 0); err != nil {
+			return nil, err
+		}
+		// the dates include the whole day
+		if to, err = parseFakeCreated(bounds[1], 24*time.Hour-time.Nanosecond); err != nil {
+			return nil, err
+		}
+	}
+	var matched []issues2markdown.Issue
+	for _, issue := range fp.issues {
+		if !issue.CreatedAt.Before(from) && !issue.CreatedAt.After(to) {
+			matched = append(matched, issue)
+		}
+	}
+	result := &issues2markdown.SearchResult{Total: len(matched)}
+	if len(matched) > fp.limit {
+		matched = matched[:fp.limit]
+	}
+	page := 0
+	if options.Page != "" {
+		_, _ = fmt.Sscan(options.Page, &page)
+	}
+	start := page * fp.pageSize
+	end := start + fp.pageSize
+	if end >= len(matched) {
+		end = len(matched)
+	} else {
+		result.NextPage = fmt.Sprint(page + 1)
+	}
+	result.Issues = matched[start:end]
+	return result, nil
+}
+
+// parseFakeCreated parses a created time or a date, adding dateOffset to the
+// dates
+func parseFakeCreated(value string, dateOffset time.Duration) (time.Time, error) {
+	if value == "*" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t.Add(dateOffset), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func newFakeLimitProvider(count int, interval time.Duration) *fakeLimitProvider {
+	provider := &fakeLimitProvider{limit: 10, pageSize: 4}
+	provider.user = &issues2markdown.User{Login: "username"}
+	start := time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= count; i++ {
+		issue := newTreeIssue(i, "repo")
+		issue.CreatedAt = start.Add(time.Duration(i) * interval)
+		provider.issues = append(provider.issues, issue)
+	}
+	return provider
+}
+
+func TestQuerySplitSearchLimit(t *testing.T) {
+	provider := newFakeLimitProvider(35, 7*time.Hour)
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "repo:username/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 35 {
+		t.Fatalf("Expected %d issues but got %d", 35, len(issues))
+	}
+	seen := map[int]bool{}
+	for _, issue := range issues {
+		if seen[issue.Number] {
+			t.Fatalf("Expected no duplicates but got #%d twice", issue.Number)
+		}
+		seen[issue.Number] = true
+	}
+	if provider.queries[0] != "type:issue repo:username/repo" {
+		t.Fatalf("Expected the query first but got %q", provider.queries[0])
+	}
+	for _, query := range provider.queries[1:] {
+		if !strings.HasPrefix(query, "type:issue repo:username/repo created:") {
+			t.Fatalf("Expected slices of the query by created time but got %q", query)
+		}
+	}
+}
+
+func TestQuerySplitBeforeStart(t *testing.T) {
+	provider := newFakeLimitProvider(35, 7*time.Hour)
+	// the issue 1 is created before the first slice bisected
+	provider.issues[0].CreatedAt = time.Date(2005, 6, 1, 0, 0, 0, 0, time.UTC)
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "repo:username/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 35 {
+		t.Fatalf("Expected %d issues but got %d", 35, len(issues))
+	}
+	if !strings.HasPrefix(provider.queries[1], "type:issue repo:username/repo created:*..") {
+		t.Fatalf("Expected an open first slice but got %q", provider.queries[1])
+	}
+}
+
+func TestQuerySplitCreatedRange(t *testing.T) {
+	provider := newFakeLimitProvider(35, 7*time.Hour)
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the issues 4 to 27 are created from 2018-10-02 to 2018-10-08
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "created:2018-10-02..2018-10-08 repo:username/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 24 {
+		t.Fatalf("Expected %d issues but got %d", 24, len(issues))
+	}
+	for _, issue := range issues {
+		if issue.Number < 4 || issue.Number > 27 {
+			t.Fatalf("Expected the issues of the created range but got #%d", issue.Number)
+		}
+	}
+}
+
+func TestQuerySplitIncompleteResults(t *testing.T) {
+	// the issues created in the same second can't be split
+	provider := newFakeLimitProvider(12, 0)
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "repo:username/repo")
+	incomplete, ok := err.(*issues2markdown.IncompleteResultsError)
+	if !ok {
+		t.Fatalf("Expected an IncompleteResultsError but got %v", err)
+	}
+	if incomplete.Total != 12 || incomplete.Retrieved != 10 {
+		t.Fatalf("Expected %d of %d results retrieved but got %+v", 10, 12, incomplete)
+	}
+	if len(issues) != 10 {
+		t.Fatalf("Expected the %d issues retrieved but got %d", 10, len(issues))
+	}
+}
+
+func TestQueryWithoutSearchLimit(t *testing.T) {
+	provider := &fakeProvider{user: &issues2markdown.User{Login: "username"}}
+	for i := 1; i <= 3; i++ {
+		provider.issues = append(provider.issues, newTreeIssue(i, "repo"))
+	}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := i2md.Query(issues2markdown.NewQueryOptions(), "repo:username/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 3 || len(provider.queries) != 3 {
+		t.Fatalf("Expected %d issues in %d pages but got %d in %d", 3, 3, len(issues), len(provider.queries))
+	}
+}
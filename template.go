@@ -0,0 +1,136 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// QueryTemplateData is the data of the query templates, like DefaultQuery,
+// the named queries and the queries provided on the CLI arguments. The
+// templates can use these functions too:
+//
+//	date EXPRESSION   the YYYY-MM-DD date of EXPRESSION, like "last monday"
+//	daysAgo N         the YYYY-MM-DD date N days ago
+//	weeksAgo N        the YYYY-MM-DD date N weeks ago
+//	monthsAgo N       the YYYY-MM-DD date N months ago
+//	env NAME          the value of the environment variable NAME, which must be set
+//	quote VALUE       VALUE quoted if it has spaces, like a label
+//
+// The missing fields and Vars are errors.
+type QueryTemplateData struct {
+	Organization string
+	// User is the login of the authorized user
+	User string
+	// Vars are the user supplied variables of the QueryOptions
+	Vars map[string]string
+	// Now is the current time
+	Now      time.Time
+	itemType ItemType
+	teams    func() ([]string, error)
+}
+
+// TypeQualifier returns the qualifier that selects the type of items, which
+// is empty for all types
+func (d *QueryTemplateData) TypeQualifier() string {
+	return ItemTypeQualifier(d.itemType).String()
+}
+
+// Teams returns the teams of the authorized user as ORGANIZATION/TEAM, like
+// the team: qualifier, if the provider is a TeamsProvider
+func (d *QueryTemplateData) Teams() ([]string, error) {
+	if d.teams == nil {
+		return nil, fmt.Errorf("teams aren't supported by the provider")
+	}
+	return d.teams()
+}
+
+// newQueryTemplate parses a query template, the dates are relative to now
+func newQueryTemplate(name string, source string, now func() time.Time) (*template.Template, error) {
+	date := func(days int, months int) string {
+		return now().AddDate(0, -months, -days).Format(searchDateLayout)
+	}
+	funcs := template.FuncMap{
+		"date": func(expression string) (string, error) {
+			t, err := parseRelativeDate(expression, now())
+			if err != nil {
+				return "", err
+			}
+			return t.Format(searchDateLayout), nil
+		},
+		"daysAgo": func(n int) string {
+			return date(n, 0)
+		},
+		"weeksAgo": func(n int) string {
+			return date(7*n, 0)
+		},
+		"monthsAgo": func(n int) string {
+			return date(0, n)
+		},
+		"env": func(name string) (string, error) {
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %s isn't set", name)
+			}
+			return value, nil
+		},
+		"quote": quoteSearchValue,
+	}
+	return template.New(name).Funcs(funcs).Option("missingkey=error").Parse(source)
+}
+
+// templateData returns the QueryTemplateData of the options
+func (qo *QueryOptions) templateData(now func() time.Time) *QueryTemplateData {
+	data := &QueryTemplateData{
+		Organization: qo.Organization,
+		Vars:         qo.Vars,
+		Now:          now(),
+		itemType:     qo.Type,
+		teams:        qo.teams,
+	}
+	if data.Vars == nil {
+		data.Vars = map[string]string{}
+	}
+	if qo.User != nil {
+		data.User = qo.User.Login
+	}
+	return data
+}
+
+// executeQueryTemplate executes a query template with the QueryTemplateData
+// of the options
+func (qo *QueryOptions) executeQueryTemplate(name string, source string) (string, error) {
+	now := qo.Now
+	if now == nil {
+		now = time.Now
+	}
+	t, err := newQueryTemplate(name, source, now)
+	if err != nil {
+		return "", err
+	}
+	var compiled bytes.Buffer
+	if err := t.Execute(&compiled, qo.templateData(now)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(compiled.String()), nil
+}
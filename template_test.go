@@ -0,0 +1,143 @@
+// Copyright 2018 The issues2markdown Authors. All rights reserved.
+//
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with this
+// work for additional information regarding copyright ownership.  The ASF
+// licenses this file to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package issues2markdown_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/issues2markdown/issues2markdown"
+)
+
+// fakeTeamsProvider is a fakeProvider with teams
+type fakeTeamsProvider struct {
+	fakeProvider
+	teams []string
+}
+
+func (fp *fakeTeamsProvider) Teams(ctx context.Context) ([]string, error) {
+	return fp.teams, nil
+}
+
+func TestBuildQueryTemplate(t *testing.T) {
+	// 2018-10-10 is a wednesday
+	now := time.Date(2018, 10, 10, 12, 0, 0, 0, time.UTC)
+	if err := os.Setenv("I2M_TEST_MILESTONE", "v1.0"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("I2M_TEST_MILESTONE")
+
+	tests := []struct {
+		q     string
+		query string
+	}{
+		{"author:{{ .Organization }}", "type:issue author:acme&co"},
+		{"assignee:{{ .User }}", "type:issue assignee:username"},
+		{"repo:{{ .Organization }}/{{ .Vars.repository }}", "type:issue repo:acme&co/repo"},
+		{"label:{{ quote .Vars.label }}", `type:issue label:"good first issue"`},
+		{"milestone:{{ env \"I2M_TEST_MILESTONE\" }}", "type:issue milestone:v1.0"},
+		{"updated:>={{ date \"last monday\" }}", "type:issue updated:>=2018-10-08"},
+		{"created:{{ daysAgo 3 }}..{{ .Now.Format \"2006-01-02\" }}", "type:issue created:2018-10-07..2018-10-10"},
+		{"updated:<{{ weeksAgo 2 }}", "type:issue updated:<2018-09-26"},
+		{"closed:>{{ monthsAgo 1 }}", "type:issue closed:>2018-09-10"},
+	}
+	for _, test := range tests {
+		options := issues2markdown.NewQueryOptions()
+		options.Organization = "acme&co"
+		options.User = &issues2markdown.User{Login: "username"}
+		options.Vars = map[string]string{"repository": "repo", "label": "good first issue"}
+		options.Now = func() time.Time { return now }
+		query, err := options.BuildQuey(test.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != test.query {
+			t.Fatalf("Expected query %q for %q but got %q", test.query, test.q, query)
+		}
+	}
+}
+
+func TestBuildQueryCustomTemplate(t *testing.T) {
+	options := issues2markdown.NewQueryOptions()
+	options.Organization = "username"
+	options.Template = "{{ .TypeQualifier }} is:open involves:{{ .Organization }}"
+
+	expectedQuery := "type:issue is:open involves:username"
+	query, err := options.BuildQuey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != expectedQuery {
+		t.Fatalf("Expected query %q but got %q", expectedQuery, query)
+	}
+}
+
+func TestBuildQueryTemplateErrors(t *testing.T) {
+	tests := []string{
+		"repo:{{ .Vars.unknown }}",
+		"team:{{ .Team }}",
+		"team:{{ range .Teams }}{{ . }}{{ end }}",
+		"milestone:{{ env \"I2M_TEST_UNSET\" }}",
+		"updated:>={{ date \"someday\" }}",
+		"label:{{ .Vars.label",
+	}
+	for _, q := range tests {
+		options := issues2markdown.NewQueryOptions()
+		options.Organization = "username"
+		if _, err := options.BuildQuey(q); err == nil {
+			t.Fatalf("Expected an error for %q", q)
+		}
+	}
+}
+
+func TestQueryTemplateTeams(t *testing.T) {
+	provider := &fakeTeamsProvider{
+		fakeProvider: fakeProvider{user: &issues2markdown.User{Login: "username"}},
+		teams:        []string{"organization/backend", "organization/frontend"},
+	}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	q := "is:open {{ range $i, $team := .Teams }}{{ if $i }} OR {{ end }}team:{{ $team }}{{ end }}"
+	if _, err := i2md.Query(options, q); err != nil {
+		t.Fatal(err)
+	}
+	expectedQuery := "type:issue is:open team:organization/backend OR team:organization/frontend"
+	if provider.queries[0] != expectedQuery {
+		t.Fatalf("Expected query %q but got %q", expectedQuery, provider.queries[0])
+	}
+}
+
+func TestQueryTemplateError(t *testing.T) {
+	provider := &fakeProvider{user: &issues2markdown.User{Login: "username"}}
+	i2md, err := issues2markdown.NewIssuesToMarkdown(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := issues2markdown.NewQueryOptions()
+	if _, err := i2md.Query(options, "team:{{ index .Teams 0 }}"); err == nil {
+		t.Fatal("Expected an error for the teams of a provider without teams")
+	}
+	if len(provider.queries) != 0 {
+		t.Fatalf("Expected no queries but got %q", provider.queries)
+	}
+}